@@ -2,90 +2,231 @@
 package bzr
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
 	"github.com/mh-cbon/verbose"
 )
 
 var logger = verbose.Auto()
 
-func getCmd(path string, args []string) (*exec.Cmd, error) {
-	bin, err := exec.LookPath("bzr")
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for bzr.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a bzr driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("bzr"))
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return nil, err
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
 	}
-	logger.Printf("%s %s (cwd=%s)", bin, args, path)
-	cmd := exec.Command(bin, args...)
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
 	return cmd, nil
 }
 
-// IsIt Test if given path is managed by bzr with bzr info
-func IsIt(path string) bool {
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
 
-	args := []string{"info"}
-	cmd, err := getCmd(path, args)
+	cmd, err := d.getCmd(ctx, path, args)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	out, err := cmd.CombinedOutput()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs bzr and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return false
+		return err
 	}
 
-	logger.Printf("out=%s", string(out))
-	return cmd.ProcessState != nil && cmd.ProcessState.Success()
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps bzr failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "Not a branch"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "already exists"):
+		return driver.ErrTagExists
+	case strings.Contains(stderr, "does not exist in branch"),
+		strings.Contains(stderr, "No such tag"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
+// IsIt Test if given path is managed by bzr with bzr info
+func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Test if given path is managed by bzr with bzr info,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"info"}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir holds a .bzr directory.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
+
+// IsRoot tells if dir holds a .bzr directory.
+func (d Driver) IsRoot(dir string) bool {
+	s, err := os.Stat(filepath.Join(dir, ".bzr"))
+	return err == nil && s.IsDir()
 }
 
 // List tags on given path
 func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists tags on given path
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
 	tags := make([]string, 0)
 
 	args := []string{"tags"}
-	cmd, err := getCmd(path, args)
+	out, err := d.run(ctx, path, args)
 	if err != nil {
 		return tags, err
 	}
 
-	out, err := cmd.CombinedOutput()
+	for _, k := range parseBzrTags(string(out)) {
+		tags = append(tags, k[0])
+	}
+	return tags, nil
+}
+
+// parseBzrTags parses bzr tags output to a list of tag name and revno,
+// the revno is ? when the tag points to a revision out of the branch.
+func parseBzrTags(out string) [][2]string {
+	ret := [][2]string{}
+	for _, v := range strings.Split(out, "\n") {
+		k := strings.Fields(v)
+		if len(k) > 1 {
+			ret = append(ret, [2]string{k[0], k[1]})
+		} else if len(k) == 1 {
+			ret = append(ret, [2]string{k[0], ""})
+		}
+	}
+	return ret
+}
+
+// ListTagsDetailed lists the tags on given path with the revisions they point to.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
+
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path,
+// bzr tags are lightweight, they have no tagger nor message.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	ret := make([]driver.Tag, 0)
+
+	out, err := d.run(ctx, path, []string{"tags"})
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return tags, err
+		return ret, err
 	}
 
-	logger.Printf("out=%s", string(out))
-	for _, v := range strings.Split(string(out), "\n") {
-		k := strings.Split(v, " ")
-		if len(k) > 0 && len(k[0]) > 0 {
-			tags = append(tags, k[0])
+	for _, k := range parseBzrTags(string(out)) {
+		tag := driver.Tag{Name: k[0], Revision: k[1]}
+		if tag.Revision != "?" && tag.Revision != "" {
+			args := []string{"log", "--log-format=long", "-n1", "-r", "tag:" + tag.Name}
+			err = d.stream(ctx, path, args, func(r io.Reader) error {
+				return walkBzrLongLogs(r, func(c commit.Commit) error {
+					tag.Date = c.Time
+					return driver.ErrStop
+				})
+			})
+			if err = driver.Stopped(err); err != nil {
+				return ret, err
+			}
 		}
+		ret = append(ret, tag)
 	}
-	return tags, nil
+	return ret, nil
 }
 
 // IsClean Check uncommited files with bzr status
 func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
 
-	args := []string{"status"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return false, err
-	}
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
 
-	out, err := cmd.CombinedOutput()
+// IsCleanContext Check uncommited files with bzr status
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"status"}
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	verb, _ := regexp.Compile("^(added|unknown|removed|modified):$")
 	changes := make([]string, 0)
 	catch := false
@@ -103,53 +244,68 @@ func IsClean(path string) (bool, error) {
 
 // CreateTag Create given tag on path with the provided message
 func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
 
-	tags, err := List(path)
+// CreateTagContext Create given tag on path with the provided message
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+
+	tags, err := d.ListContext(ctx, path)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, "", err
 	}
 
 	if len(message) > 0 {
-		logger.Println("Unused message: " + message)
+		d.logger().Println("Unused message: " + message)
 	}
 
 	if contains(tags, tag) {
-		return false, "", errors.New("Tag '" + tag + "' already exists")
+		return false, "", fmt.Errorf("%w: %s", driver.ErrTagExists, tag)
 	}
 
 	args := []string{"tag", tag}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return false, "", err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	out, err := d.run(ctx, path, args)
 	return err == nil, string(out), err
 }
 
 // Add given file to bzr on path
 func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to bzr on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
 
 	args := []string{"add"}
 	if len(file) > 0 {
 		args = append(args, []string{file}...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
 // Commit given files with message on path
 func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext commits given files with message on path
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
 
 	if len(message) == 0 {
 		return errors.New("Message is required")
@@ -159,55 +315,231 @@ func Commit(path string, message string, files []string) error {
 	if len(files) > 0 {
 		args = append(args, files...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
 // ListCommitsBetween List commits between two points
 func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two points
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
 	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while bzr log prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points, while bzr log prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommits calls fn with each commit selected by opts, while bzr log prints them
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while bzr log prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
 
 	if to == "HEAD" {
 		to = ""
 	}
 
-	args := []string{"log"}
+	args := []string{"log", "--log-format=long"}
+	if d.Files {
+		args = append(args, "-v")
+	}
 	if len(since)+len(to) > 0 {
 		if since == "" {
 			since = "revno:1"
-		} else if IsTag(path, since) {
+		} else if d.IsTagContext(ctx, path, since) {
 			since = "tag:" + since
 		}
-		if to != "" && IsTag(path, to) {
+		if to != "" && d.IsTagContext(ctx, path, to) {
 			to = "tag:" + to
 		}
 		args = append(args, "-r", since+".."+to)
 	}
-	cmd, err := getCmd(path, args)
+
+	filter := opts.CommitFilter
+	paths := filter.Paths
+	filter.Paths = nil
+	if n := filter.Limit(); n > 0 {
+		args = append(args, "-l", strconv.Itoa(n))
+	}
+	args = append(args, paths...)
+	fn, err := filter.Filter(fn)
 	if err != nil {
-		return ret, err
+		return err
 	}
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	walked := false
+	walk := func(c commit.Commit) error {
+		walked = true
+		c.Complete()
+		return fn(c)
+	}
+
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkBzrLongLogs(r, walk)
+	})
+	if err == nil || walked || errors.Is(err, errUnexpectedLog) == false {
+		return driver.Stopped(err)
+	}
 
-	ret = ParseBzrLogs(string(out))
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkBzrLogs(r, walk)
+	})
+	return driver.Stopped(err)
+}
 
+// ParseBzrLongLogs parses bzr log --log-format=long output to a list of commits.
+// The fields of a revision start at the first column, the message and the files are indented,
+// thus a message can not be mistaken for a field.
+// The parent of a mainline revision is the previous revno.
+func ParseBzrLongLogs(log string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := walkBzrLongLogs(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
 	return ret, err
 }
 
-// ParseBzrLogs parses bzr log output to a list of commits
+// errUnexpectedLog is returned when the output of bzr log does not match the long format.
+var errUnexpectedLog = errors.New("Unexpected bzr log line")
+
+// walkBzrLongLogs calls fn with each commit of r, see ParseBzrLongLogs.
+func walkBzrLongLogs(r io.Reader, fn driver.WalkFunc) error {
+	splitRe := regexp.MustCompile(`^-{60}$`)
+	fieldRe := regexp.MustCompile(`^([a-z ]+):\s?(.*)$`)
+	userRe := regexp.MustCompile(`^([^<]*)<([^>]+)>$`)
+	actions := map[string]commit.Action{
+		"added":    commit.Added,
+		"modified": commit.Modified,
+		"removed":  commit.Deleted,
+		"renamed":  commit.Renamed,
+	}
+	section := ""
+	var c *commit.Commit
+	var message []string
+	author := ""
+	end := func() error {
+		if c == nil {
+			return nil
+		}
+		if author == "" {
+			author = c.Committer
+		}
+		if res := userRe.FindStringSubmatch(author); len(res) > 0 {
+			c.Author = strings.TrimSpace(res[1])
+			c.Email = res[2]
+		} else {
+			c.Author = author
+		}
+		if res := userRe.FindStringSubmatch(c.Committer); len(res) > 0 {
+			c.Committer = strings.TrimSpace(res[1])
+			c.CommitterEmail = res[2]
+		}
+		c.Message = strings.TrimSpace(strings.Join(message, "\n"))
+		c.Complete()
+		return fn(*c)
+	}
+	err := driver.Lines(r, func(line string) error {
+		if splitRe.MatchString(line) {
+			if err := end(); err != nil {
+				return err
+			}
+			c = &commit.Commit{}
+			message = []string{}
+			author = ""
+			section = ""
+		} else if c != nil && section == "message" && (line == "" || strings.HasPrefix(line, "  ")) {
+			message = append(message, strings.TrimPrefix(line, "  "))
+		} else if c != nil && section != "" && strings.HasPrefix(line, "  ") {
+			if action, ok := actions[section]; ok {
+				f := commit.File{Action: action, Path: strings.TrimSpace(line)}
+				if action == commit.Renamed {
+					k := strings.SplitN(f.Path, " => ", 2)
+					if len(k) == 2 {
+						f.From, f.Path = k[0], k[1]
+					}
+				}
+				c.Files = append(c.Files, f)
+			}
+		} else if c != nil && fieldRe.MatchString(line) {
+			res := fieldRe.FindStringSubmatch(line)
+			section = ""
+			switch res[1] {
+			case "revno":
+				k := strings.Split(res[2], " ")
+				c.Revision = k[0]
+				if n, err := strconv.Atoi(c.Revision); err == nil && n > 1 {
+					c.Parents = []string{strconv.Itoa(n - 1)}
+				}
+				c.Merge = len(k) > 1 && k[1] == "[merge]"
+			case "committer":
+				c.Committer = res[2]
+			case "author", "authors":
+				author = strings.Split(res[2], ", ")[0]
+			case "branch nick":
+				c.Branch = res[2]
+			case "timestamp":
+				c.Date = res[2]
+			default:
+				section = res[1]
+			}
+		} else if strings.TrimSpace(line) != "" {
+			return fmt.Errorf("%w %q", errUnexpectedLog, line)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return end()
+}
+
+// ParseBzrLogs parses bzr log output to a list of commits,
+// it is used when ParseBzrLongLogs fails.
 func ParseBzrLogs(log string) []commit.Commit {
 	ret := make([]commit.Commit, 0)
+	walkBzrLogs(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret
+}
 
+// walkBzrLogs calls fn with each commit of r, see ParseBzrLogs.
+func walkBzrLogs(r io.Reader, fn driver.WalkFunc) error {
 	splitRe := regexp.MustCompile(`^[-]+$`)
 	commitRe := regexp.MustCompile(`^revno:\s+([0-9]+)$`)
 	authorRe := regexp.MustCompile(`^committer:\s+([^<]+)\s+<([^>]+)>$`)
@@ -215,11 +547,13 @@ func ParseBzrLogs(log string) []commit.Commit {
 	messageRe := regexp.MustCompile(`message:$`)
 	isInMessage := false
 	var c *commit.Commit
-	for _, line := range strings.Split(log, "\n") {
+	err := driver.Lines(r, func(line string) error {
 		line = strings.TrimSpace(line)
 		if splitRe.MatchString(line) {
 			if c != nil {
-				ret = append(ret, *c)
+				if err := fn(*c); err != nil {
+					return err
+				}
 			}
 			c = &commit.Commit{}
 			isInMessage = false
@@ -242,37 +576,38 @@ func ParseBzrLogs(log string) []commit.Commit {
 				c.Message = c.Message + "\n" + line
 			}
 		}
+		return nil
+	})
+	if err == nil && c != nil && c.Revision != "" {
+		err = fn(*c)
 	}
-	if c != nil && c.Revision != "" {
-		ret = append(ret, *c)
-	}
-	return ret
+	return err
 }
 
 // GetRevisionTag Get revision of a tag
 func GetRevisionTag(path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(context.Background(), path, tag)
+}
+
+// GetRevisionTagContext is like GetRevisionTag, bounded by ctx.
+func GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(ctx, path, tag)
+}
+
+// GetRevisionTagContext Get revision of a tag
+func (d Driver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
 	ret := ""
 
 	args := []string{"tags"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return ret, err
-	}
-
-	out, err := cmd.CombinedOutput()
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return ret, err
 	}
 
-	logger.Printf("out=%s", string(out))
-	for _, v := range strings.Split(string(out), "\n") {
-		k := strings.Split(v, " ")
-		if len(k) > 0 && len(k[0]) > 0 {
-			if k[0] == tag {
-				ret = strings.TrimSpace(k[1])
-				break
-			}
+	for _, k := range parseBzrTags(string(out)) {
+		if k[0] == tag {
+			ret = k[1]
+			break
 		}
 	}
 	return ret, nil
@@ -280,7 +615,17 @@ func GetRevisionTag(path string, tag string) (string, error) {
 
 // IsTag tells if given string is a tag
 func IsTag(path string, tag string) bool {
-	tags, err := List(path)
+	return Driver{}.IsTagContext(context.Background(), path, tag)
+}
+
+// IsTagContext is like IsTag, bounded by ctx.
+func IsTagContext(ctx context.Context, path string, tag string) bool {
+	return Driver{}.IsTagContext(ctx, path, tag)
+}
+
+// IsTagContext tells if given string is a tag
+func (d Driver) IsTagContext(ctx context.Context, path string, tag string) bool {
+	tags, err := d.ListContext(ctx, path)
 	if err != nil {
 		return false
 	}
@@ -290,6 +635,16 @@ func IsTag(path string, tag string) bool {
 
 // GetFirstRevision  returns the first revision of the repository.
 func GetFirstRevision(path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
+
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
+
+// GetFirstRevisionContext  returns the first revision of the repository.
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
 	return "revno:1", nil
 }
 
@@ -0,0 +1,65 @@
+package bzr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestParseBzrLongLogs(t *testing.T) {
+	log := `------------------------------------------------------------
+revno: 2 [merge]
+tags: v1.0.0
+committer: John Doe <john@example.com>
+author: Jane Doe <jane@example.com>
+branch nick: bzr
+timestamp: Sun 2017-01-01 10:00:00 +0200
+message:
+  tomate 1.0.0
+  
+  revno: 12
+  committer: x <y>
+added:
+  b
+renamed:
+  a => c
+------------------------------------------------------------
+revno: 1
+committer: John Doe <john@example.com>
+branch nick: bzr
+timestamp: Sat 2016-12-31 10:00:00 +0200
+message:
+  tomate notsemvertag
+`
+	commits, err := ParseBzrLongLogs(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+	c := commits[0]
+	if c.Revision != "2" || c.Author != "Jane Doe" || c.Email != "jane@example.com" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	if c.Committer != "John Doe" || c.Branch != "bzr" || c.Merge == false || reflect.DeepEqual(c.Parents, []string{"1"}) == false {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	files := []commit.File{
+		{Action: commit.Added, Path: "b"},
+		{Action: commit.Renamed, Path: "c", From: "a"},
+	}
+	if reflect.DeepEqual(c.Files, files) == false {
+		t.Errorf("Expected files %+v, got %+v", files, c.Files)
+	}
+	if c.Message != "tomate 1.0.0\n\nrevno: 12\ncommitter: x <y>" {
+		t.Errorf("Unexpected message %q", c.Message)
+	}
+	if c.Date != "2017-01-01T10:00:00+02:00" || c.GetDate() == nil {
+		t.Errorf("Unexpected date %q", c.Date)
+	}
+	if commits[1].Message != "tomate notsemvertag" {
+		t.Errorf("Unexpected message %q", commits[1].Message)
+	}
+}
@@ -0,0 +1,237 @@
+// Package changelog builds the release notes of a repository from its semver tags.
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/commit/conventional"
+)
+
+// Lister lists the commits between two points, as repoutils.Repo.
+type Lister interface {
+	CommitsContext(ctx context.Context, since string, to string) ([]commit.Commit, error)
+}
+
+// Release is a version and the commits it introduced, the unreleased commits have no version.
+type Release struct {
+	Version  string    `json:"version"`
+	Previous string    `json:"previous,omitempty"`
+	Date     time.Time `json:"date"`
+	Changes  []Change  `json:"changes"`
+	// Sections groups the changes by conventional commit type, when it is asked.
+	Sections []Section `json:"sections,omitempty"`
+}
+
+// Change is a commit of a release, with its conventional commit fields when it is asked
+// and its message follows the convention.
+type Change struct {
+	commit.Commit
+	Conventional *conventional.Commit `json:"conventional,omitempty"`
+}
+
+// Section is a titled group of changes.
+type Section struct {
+	Title   string   `json:"title"`
+	Changes []Change `json:"changes"`
+}
+
+// Build returns the releases of tags, which are sorted in ascending semver order,
+// the newest release first.
+// The commits up to the first tag make its release, those after the last tag make
+// a release without version when there are some.
+// With withConventional, the changes are grouped by type into sections.
+func Build(repo Lister, tags []string, withConventional bool) ([]Release, error) {
+	return BuildContext(context.Background(), repo, tags, withConventional)
+}
+
+// BuildContext is like Build, bounded by ctx.
+func BuildContext(ctx context.Context, repo Lister, tags []string, withConventional bool) ([]Release, error) {
+	ret := make([]Release, 0, len(tags)+1)
+	points := append(append([]string{""}, tags...), "")
+	for i := 1; i < len(points); i++ {
+		since, version := points[i-1], points[i]
+		to := version
+		if to == "" {
+			to = "HEAD"
+		}
+		commits, err := repo.CommitsContext(ctx, since, to)
+		if err != nil {
+			return ret, fmt.Errorf("listing the commits between %q and %q: %w", since, to, err)
+		}
+		if version == "" && len(commits) == 0 {
+			continue
+		}
+		ret = append([]Release{newRelease(version, since, commits, withConventional)}, ret...)
+	}
+	return ret, nil
+}
+
+func newRelease(version, previous string, commits []commit.Commit, withConventional bool) Release {
+	commit.Commits(commits).OrderByDate("DESC")
+	r := Release{Version: version, Previous: previous, Changes: make([]Change, 0, len(commits))}
+	for _, c := range commits {
+		change := Change{Commit: c}
+		if withConventional {
+			change.Conventional, _ = conventional.Parse(c.Message)
+		}
+		r.Changes = append(r.Changes, change)
+	}
+	if len(commits) > 0 {
+		if d := commits[0].GetDate(); d != nil {
+			r.Date = *d
+		}
+	}
+	if withConventional {
+		r.Sections = group(r.Changes)
+	}
+	return r
+}
+
+// sections are the titles of the conventional commit types listed first,
+// the other types follow as Other changes.
+var sections = []struct{ Type, Title string }{
+	{"feat", "Features"},
+	{"fix", "Bug fixes"},
+	{"perf", "Performance"},
+	{"revert", "Reverts"},
+}
+
+// group returns the non empty sections of changes,
+// the breaking changes are also listed in a first section.
+func group(changes []Change) []Section {
+	grouped := []Section{{Title: "Breaking changes"}}
+	for _, s := range sections {
+		grouped = append(grouped, Section{Title: s.Title})
+	}
+	grouped = append(grouped, Section{Title: "Other changes"})
+
+	for _, c := range changes {
+		if c.Conventional != nil && c.Conventional.Breaking {
+			grouped[0].Changes = append(grouped[0].Changes, c)
+		}
+		k := len(grouped) - 1
+		for i, s := range sections {
+			if c.Conventional != nil && c.Conventional.Type == s.Type {
+				k = i + 1
+			}
+		}
+		grouped[k].Changes = append(grouped[k].Changes, c)
+	}
+
+	ret := []Section{}
+	for _, s := range grouped {
+		if len(s.Changes) > 0 {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// Title returns the version of r, or UNRELEASED when it has none.
+func (r Release) Title() string {
+	if r.Version == "" {
+		return "UNRELEASED"
+	}
+	return r.Version
+}
+
+// Contributors returns the authors of the changes as "name <email>", in their order, once.
+func (r Release) Contributors() []string {
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, c := range r.Changes {
+		author := authorOf(c.Commit)
+		if author != "" && seen[author] == false {
+			seen[author] = true
+			ret = append(ret, author)
+		}
+	}
+	return ret
+}
+
+func authorOf(c commit.Commit) string {
+	if c.Email == "" {
+		return c.Author
+	}
+	return strings.TrimSpace(c.Author + " <" + c.Email + ">")
+}
+
+// WriteChangeLog writes releases in the change.log format, the sections are not written.
+func WriteChangeLog(w io.Writer, releases []Release) error {
+	var b strings.Builder
+	for _, r := range releases {
+		b.WriteString("\n" + r.Title() + "\n\n")
+		for _, c := range r.Changes {
+			b.WriteString("  * " + c.Subject + "\n")
+		}
+		b.WriteString("\n")
+		for _, author := range r.Contributors() {
+			b.WriteString("  - " + author + "\n")
+		}
+		b.WriteString("\n")
+		if len(r.Changes) > 0 {
+			b.WriteString("-- " + authorOf(r.Changes[0].Commit) + "; " + formatDate(r.Date, "Mon, 02 Jan 2006 15:04:05 -0700") + "\n")
+		}
+		b.WriteString("\n\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes releases as markdown, without title,
+// the changes are listed by section when there are some.
+func WriteMarkdown(w io.Writer, releases []Release) error {
+	var b strings.Builder
+	for _, r := range releases {
+		b.WriteString("### " + r.Title() + "\n\n")
+		sections := r.Sections
+		if len(sections) == 0 {
+			sections = []Section{{Title: "Changes", Changes: r.Changes}}
+		}
+		for _, s := range sections {
+			b.WriteString("__" + s.Title + "__\n\n")
+			for _, c := range s.Changes {
+				b.WriteString("- " + markdownItem(c, s.Title == "Breaking changes") + "\n")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("__Contributors__\n\n")
+		for _, author := range r.Contributors() {
+			b.WriteString("- " + author + "\n")
+		}
+		b.WriteString("\n")
+		if r.Version != "" {
+			b.WriteString("Released " + formatDate(r.Date, "Mon 02 Jan 2006") + "\n")
+		}
+		b.WriteString("______________\n\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownItem returns the line of c, its breaking notes when breaking is true.
+func markdownItem(c Change, breaking bool) string {
+	if c.Conventional == nil {
+		return c.Subject
+	}
+	item := c.Conventional.Description
+	if breaking {
+		item = strings.Join(strings.Fields(strings.Join(c.Conventional.BreakingChanges, " ")), " ")
+	}
+	if c.Conventional.Scope != "" {
+		item = "**" + c.Conventional.Scope + ":** " + item
+	}
+	return item
+}
+
+func formatDate(d time.Time, layout string) string {
+	if d.IsZero() {
+		return "unknown date"
+	}
+	return d.Format(layout)
+}
@@ -0,0 +1,127 @@
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+type lister map[string][]commit.Commit
+
+func (l lister) CommitsContext(ctx context.Context, since string, to string) ([]commit.Commit, error) {
+	return l[since+".."+to], nil
+}
+
+func testLister() lister {
+	c := func(rev, date, message string) commit.Commit {
+		ret := commit.Commit{Revision: rev, Author: "John", Email: "john@doe.com", Date: date, Message: message}
+		ret.Complete()
+		return ret
+	}
+	return lister{
+		"..1.0.0": {
+			c("1", "2016-05-01T10:00:00Z", "init"),
+		},
+		"1.0.0..1.1.0": {
+			c("2", "2016-05-02T10:00:00Z", "fix(api): check the path"),
+			c("3", "2016-05-03T10:00:00Z", "feat!: drop the old format"),
+		},
+		"1.1.0..HEAD": {
+			c("4", "2016-05-04T10:00:00Z", "docs: readme"),
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	releases, err := Build(testLister(), []string{"1.0.0", "1.1.0"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, r := range releases {
+		s := r.Title() + ":"
+		for _, c := range r.Changes {
+			s += c.Revision
+		}
+		for _, section := range r.Sections {
+			s += " " + section.Title
+		}
+		got = append(got, s)
+	}
+	expected := "UNRELEASED:4 Other changes|1.1.0:32 Breaking changes Features Bug fixes|1.0.0:1 Other changes"
+	if strings.Join(got, "|") != expected {
+		t.Errorf("Expected %q, got %q", expected, strings.Join(got, "|"))
+	}
+
+	releases, err = Build(testLister(), []string{"1.0.0", "1.1.0", "1.2.0"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 3 || releases[0].Version != "1.2.0" || len(releases[0].Sections) > 0 {
+		t.Errorf("Unexpected releases %+v", releases)
+	}
+}
+
+func TestWriteChangeLog(t *testing.T) {
+	releases, err := Build(testLister(), []string{"1.0.0"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := WriteChangeLog(&b, releases); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+1.0.0
+
+  * init
+
+  - John <john@doe.com>
+
+-- John <john@doe.com>; Sun, 01 May 2016 10:00:00 +0000
+
+
+`
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	releases, err := Build(testLister(), []string{"1.0.0", "1.1.0"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := WriteMarkdown(&b, releases[1:2]); err != nil {
+		t.Fatal(err)
+	}
+	expected := `### 1.1.0
+
+__Breaking changes__
+
+- drop the old format
+
+__Features__
+
+- drop the old format
+
+__Bug fixes__
+
+- **api:** check the path
+
+__Contributors__
+
+- John <john@doe.com>
+
+Released Tue 03 May 2016
+______________
+
+`
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+}
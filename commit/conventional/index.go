@@ -0,0 +1,106 @@
+// Package conventional parses commit messages written with the Conventional Commits convention,
+// https://www.conventionalcommits.org.
+package conventional
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrNotConventional is returned when the header of a message is not type(scope)!: description.
+var ErrNotConventional = errors.New("not a conventional commit")
+
+// Commit is a parsed conventional commit message.
+type Commit struct {
+	Type        string   `json:"type"`
+	Scope       string   `json:"scope,omitempty"`
+	Breaking    bool     `json:"breaking,omitempty"`
+	Description string   `json:"description"`
+	Body        string   `json:"body,omitempty"`
+	Footers     []Footer `json:"footers,omitempty"`
+	// BreakingChanges are the BREAKING CHANGE notes,
+	// the description when the header is marked with ! and no note is given.
+	BreakingChanges []string `json:"breaking_changes,omitempty"`
+}
+
+// Footer is a token: value or token #value line of the footers, its value may be folded on several lines.
+type Footer struct {
+	Token string `json:"token"`
+	Value string `json:"value"`
+}
+
+// IsBreakingChange tells if the footer token is BREAKING CHANGE, or its synonym BREAKING-CHANGE.
+func (f Footer) IsBreakingChange() bool {
+	return f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE"
+}
+
+var (
+	headerRe = regexp.MustCompile(`^([a-zA-Z0-9_-]+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+	footerRe = regexp.MustCompile(`^(BREAKING CHANGE|[a-zA-Z0-9-]+)(: | #)(.*)$`)
+)
+
+// Parse parses a commit message, it fails with ErrNotConventional
+// when the first line of message is not a conventional header.
+func Parse(message string) (*Commit, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n")
+	res := headerRe.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if res == nil {
+		return nil, ErrNotConventional
+	}
+	c := &Commit{
+		Type:        res[1],
+		Scope:       strings.TrimSpace(res[2]),
+		Breaking:    res[3] == "!",
+		Description: strings.TrimSpace(res[4]),
+	}
+
+	// the footers are the last paragraphs starting with a footer line
+	lines = lines[1:]
+	start := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+			continue
+		}
+		if footerRe.MatchString(lines[i]) == false {
+			if strings.TrimSpace(lines[i]) != "" {
+				break
+			}
+			continue
+		}
+		start = i
+	}
+	c.Body = strings.TrimSpace(strings.Join(lines[:start], "\n"))
+	c.Footers = parseFooters(lines[start:])
+
+	for _, f := range c.Footers {
+		if f.IsBreakingChange() {
+			c.Breaking = true
+			c.BreakingChanges = append(c.BreakingChanges, f.Value)
+		}
+	}
+	if c.Breaking && len(c.BreakingChanges) == 0 {
+		c.BreakingChanges = []string{c.Description}
+	}
+	return c, nil
+}
+
+// parseFooters parses the footer lines, a line which is not a footer continues the value of the previous one.
+func parseFooters(lines []string) []Footer {
+	var footers []Footer
+	for _, line := range lines {
+		if res := footerRe.FindStringSubmatch(line); res != nil {
+			value := res[3]
+			if res[2] == " #" {
+				value = "#" + value
+			}
+			footers = append(footers, Footer{Token: res[1], Value: value})
+		} else if len(footers) > 0 {
+			footers[len(footers)-1].Value += "\n" + strings.TrimSpace(line)
+		}
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimSpace(footers[i].Value)
+	}
+	return footers
+}
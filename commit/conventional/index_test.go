@@ -0,0 +1,62 @@
+package conventional
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	message := `feat(api)!: send an email on signup
+
+Introduce a request id.
+
+Reviewed-by: Z
+Refs #133
+BREAKING CHANGE: the signup returns 201,
+  not 200.`
+
+	c, err := Parse(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Commit{
+		Type:        "feat",
+		Scope:       "api",
+		Breaking:    true,
+		Description: "send an email on signup",
+		Body:        "Introduce a request id.",
+		Footers: []Footer{
+			{Token: "Reviewed-by", Value: "Z"},
+			{Token: "Refs", Value: "#133"},
+			{Token: "BREAKING CHANGE", Value: "the signup returns 201,\nnot 200."},
+		},
+		BreakingChanges: []string{"the signup returns 201,\nnot 200."},
+	}
+	if reflect.DeepEqual(c, expected) == false {
+		t.Errorf("Expected %+v, got %+v", expected, c)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	c, err := Parse("fix!: drop node 6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Type != "fix" || c.Scope != "" || c.Breaking == false || reflect.DeepEqual(c.BreakingChanges, []string{"drop node 6"}) == false {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+
+	c, err = Parse("docs: correct spelling\n\nNote: the body, not a footer\n\nas it is followed by text\n\nFixes: #1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Body != "Note: the body, not a footer\n\nas it is followed by text" || len(c.Footers) != 1 || c.Breaking {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+
+	for _, message := range []string{"tomate", "feat:no space", "feat(api: missing paren", ""} {
+		if _, err := Parse(message); err != ErrNotConventional {
+			t.Errorf("Parse(%q): expected ErrNotConventional, got %v", message, err)
+		}
+	}
+}
@@ -1,63 +1,171 @@
 package commit
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
 type Commit struct {
-	Revision string `json:"revision"`
-	Author   string `json:"author,omitempty"`
-	Email    string `json:"email,omitempty"`
-	Date     string `json:"date,omitempty"`
-	Message  string `json:"message,omitempty"`
+	Revision       string    `json:"revision"`
+	ShortRevision  string    `json:"short_revision,omitempty"`
+	Parents        []string  `json:"parents,omitempty"`
+	Merge          bool      `json:"merge,omitempty"`
+	Branch         string    `json:"branch,omitempty"`
+	Author         string    `json:"author,omitempty"`
+	Email          string    `json:"email,omitempty"`
+	Date           string    `json:"date,omitempty"`
+	Time           time.Time `json:"time"`
+	Committer      string    `json:"committer,omitempty"`
+	CommitterEmail string    `json:"committer_email,omitempty"`
+	CommitterTime  time.Time `json:"committer_time"`
+	Message        string    `json:"message,omitempty"`
+	Subject        string    `json:"subject,omitempty"`
+	Body           string    `json:"body,omitempty"`
+	Trailers       []Trailer `json:"trailers,omitempty"`
+	Files          []File    `json:"files,omitempty"`
 }
 
-func (c Commit) GetDate() *time.Time {
-	if c.Date == "" {
-		return nil
+// Action is the change made to a file by a commit.
+type Action string
+
+// Actions a commit can make on a file.
+const (
+	Added    Action = "added"
+	Modified Action = "modified"
+	Deleted  Action = "deleted"
+	Renamed  Action = "renamed"
+)
+
+// File is a file changed by a commit, From is the previous path of a renamed file.
+type File struct {
+	Action Action `json:"action"`
+	Path   string `json:"path"`
+	From   string `json:"from,omitempty"`
+}
+
+// Complete fills the fields which can be derived from the others:
+// Subject, Body and Trailers from Message, ShortRevision from Revision, Merge from Parents,
+// Time from Date, and the committer from the author when it is missing.
+// Date is rewritten in RFC 3339 when it is known, it is left untouched otherwise.
+func (c *Commit) Complete() {
+	if c.Subject == "" && c.Body == "" {
+		c.Subject, c.Body = SplitMessage(c.Message)
 	}
-	d, err := time.Parse("Mon 2006-01-02 15:04:05 -0700", c.Date)
-	if err == nil {
-		return &d
+	if c.Trailers == nil {
+		c.Trailers = ParseTrailers(c.Message)
 	}
-	d, err = time.Parse("Mon 2006-1-2 15:04:05 -0700", c.Date)
-	if err == nil {
-		return &d
+	if c.ShortRevision == "" {
+		c.ShortRevision = c.Revision
+		if len(c.Revision) > 12 {
+			c.ShortRevision = c.Revision[:12]
+		}
 	}
-	d, err = time.Parse("Mon Jan 02 15:04:05 2006 -0700", c.Date)
-	if err == nil {
-		return &d
+	if len(c.Parents) > 1 {
+		c.Merge = true
 	}
-	d, err = time.Parse("Mon Jan 2 15:04:05 2006 -0700", c.Date)
-	if err == nil {
-		return &d
+	if c.Time.IsZero() {
+		if d, err := ParseDate(c.Date); err == nil {
+			c.Time = d
+		}
 	}
-	d, err = time.Parse("2006-01-02 15:04:05 -0700", c.Date)
-	if err == nil {
-		return &d
+	if c.Time.IsZero() == false {
+		c.Date = c.Time.Format(time.RFC3339)
+	}
+	if c.Committer == "" && c.CommitterEmail == "" {
+		c.Committer = c.Author
+		c.CommitterEmail = c.Email
 	}
-	d, err = time.Parse("2006-1-2 15:04:05 -0700", c.Date)
-	if err == nil {
+	if c.CommitterTime.IsZero() {
+		c.CommitterTime = c.Time
+	}
+}
+
+// SplitMessage returns the first line of message, and the remaining lines.
+func SplitMessage(message string) (string, string) {
+	message = strings.TrimSpace(message)
+	k := strings.SplitN(message, "\n", 2)
+	if len(k) == 1 {
+		return k[0], ""
+	}
+	return strings.TrimSpace(k[0]), strings.TrimSpace(k[1])
+}
+
+// GetDate returns the date of the commit, nil when it is unknown.
+func (c Commit) GetDate() *time.Time {
+	if c.Time.IsZero() == false {
+		d := c.Time
 		return &d
 	}
-	return nil
+	d, err := ParseDate(c.Date)
+	if err != nil {
+		return nil
+	}
+	return &d
+}
+
+// dateLayouts are the date layouts printed by the vcs, as understood by ParseDate.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"Mon 2006-01-02 15:04:05 -0700",
+	"Mon 2006-1-2 15:04:05 -0700",
+	"Mon Jan 02 15:04:05 2006 -0700",
+	"Mon Jan 2 15:04:05 2006 -0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-1-2 15:04:05 -0700",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"20060102150405",
+	"2006-01-02",
+}
+
+// ParseDate parses a date printed by one of the vcs,
+// a trailing comment such as svn's " (Sun, 01 May 2016)" is ignored.
+// Dates without a zone are UTC, the others keep their offset whatever the local zone is.
+func ParseDate(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if i := strings.Index(date, " ("); i > -1 && strings.HasSuffix(date, ")") {
+		date = date[:i]
+	}
+	for _, layout := range dateLayouts {
+		if d, err := time.Parse(layout, date); err == nil {
+			_, offset := d.Zone()
+			if offset == 0 {
+				return d.UTC(), nil
+			}
+			return d.In(time.FixedZone("", offset)), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unrecognized date %q", date)
 }
 
 type Commits []Commit
 type CommitsAsc Commits
 type CommitsDesc Commits
 
+// OrderByDate sorts the commits by date in the direction dir, ASC or DESC.
+// The commits with an unknown date are sorted last, in their original order.
 func (c Commits) OrderByDate(dir string) {
 	if dir == "" {
 		dir = "ASC"
 	}
 	if dir == "ASC" {
-		sort.Sort(CommitsAsc(c))
+		sort.Stable(CommitsAsc(c))
 	} else if dir == "DESC" {
-		sort.Sort(CommitsDesc(c))
+		sort.Stable(CommitsDesc(c))
 	}
 }
+
+// Complete fills the derived fields of each commit, see Commit.Complete.
+func (c Commits) Complete() {
+	for i := range c {
+		c[i].Complete()
+	}
+}
+
 func (c Commits) Reverse() {
 	for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
 		c[i], c[j] = c[j], c[i]
@@ -72,15 +180,7 @@ func (s CommitsAsc) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 func (s CommitsAsc) Less(i, j int) bool {
-	d1 := s[i].GetDate()
-	if d1 == nil {
-		return false
-	}
-	d2 := s[j].GetDate()
-	if d2 == nil {
-		return false
-	}
-	return d2.After(*d1)
+	return lessDate(s[i], s[j], false)
 }
 func (s CommitsDesc) Len() int {
 	return len(s)
@@ -89,13 +189,17 @@ func (s CommitsDesc) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 func (s CommitsDesc) Less(i, j int) bool {
-	d1 := s[i].GetDate()
-	if d1 == nil {
-		return false
+	return lessDate(s[i], s[j], true)
+}
+
+// lessDate tells if a sorts before b, the unknown dates sort last.
+func lessDate(a, b Commit, desc bool) bool {
+	d1, d2 := a.GetDate(), b.GetDate()
+	if d1 == nil || d2 == nil {
+		return d1 != nil && d2 == nil
 	}
-	d2 := s[j].GetDate()
-	if d2 == nil {
-		return false
+	if desc {
+		return d1.After(*d2)
 	}
-	return d1.After(*d2)
+	return d2.After(*d1)
 }
@@ -0,0 +1,95 @@
+package commit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	dates := map[string]string{
+		"2016-05-01T12:00:00+02:00":                    "2016-05-01T12:00:00+02:00",
+		"2016-05-01 12:00:00 +0200 (Sun, 01 May 2016)": "2016-05-01T12:00:00+02:00",
+		"Sun May 1 12:00:00 2016 +0200":                "2016-05-01T12:00:00+02:00",
+		"Sun 2016-05-01 12:00:00 +0200":                "2016-05-01T12:00:00+02:00",
+		"2016-05-01 10:00:00":                          "2016-05-01T10:00:00Z",
+		"2016-05-01 10:00:00.123456 UTC":               "2016-05-01T10:00:00Z",
+		"20160501100000":                               "2016-05-01T10:00:00Z",
+	}
+	for date, expected := range dates {
+		d, err := ParseDate(date)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %s", date, err)
+			continue
+		}
+		if got := d.Format(time.RFC3339); got != expected {
+			t.Errorf("ParseDate(%q): expected %q, got %q", date, expected, got)
+		}
+	}
+	if _, err := ParseDate("yesterday"); err == nil {
+		t.Errorf("Expected an error for an unrecognized date")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	c := Commit{Date: "2016-05-01 12:00:00 +0200 (Sun, 01 May 2016)"}
+	c.Complete()
+	if c.Date != "2016-05-01T12:00:00+02:00" || c.Time.IsZero() {
+		t.Errorf("Unexpected date %q %v", c.Date, c.Time)
+	}
+	c = Commit{Date: "yesterday"}
+	c.Complete()
+	if c.Date != "yesterday" || c.Time.IsZero() == false {
+		t.Errorf("Unexpected date %q %v", c.Date, c.Time)
+	}
+}
+
+func TestOrderByDate(t *testing.T) {
+	commits := Commits{
+		{Revision: "a", Date: "unknown"},
+		{Revision: "b", Date: "2016-05-02T00:00:00Z"},
+		{Revision: "c"},
+		{Revision: "d", Date: "2016-05-01T00:00:00Z"},
+		{Revision: "e", Date: "2016-05-03T00:00:00Z"},
+	}
+	order := func() string {
+		s := ""
+		for _, c := range commits {
+			s += c.Revision
+		}
+		return s
+	}
+	commits.OrderByDate("ASC")
+	if got := order(); got != "dbeac" {
+		t.Errorf("ASC: expected %q, got %q", "dbeac", got)
+	}
+	commits.OrderByDate("DESC")
+	if got := order(); got != "ebdac" {
+		t.Errorf("DESC: expected %q, got %q", "ebdac", got)
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	messages := map[string][]Trailer{
+		"fix: a":                 nil,
+		"Signed-off-by: John":    nil,
+		"fix: a\n\nsome text":    nil,
+		"fix: a\n\nFixes: #12\n": {{Key: "Fixes", Value: "#12"}},
+		"fix: a\n\nbody\n\nSigned-off-by: John Doe <john@doe.com>\nCo-authored-by: Jane Doe\n  <jane@doe.com>\nReviewed-by : Bob": {
+			{Key: "Signed-off-by", Value: "John Doe <john@doe.com>"},
+			{Key: "Co-authored-by", Value: "Jane Doe <jane@doe.com>"},
+			{Key: "Reviewed-by", Value: "Bob"},
+		},
+		"fix: a\n\nFixes: #12\nnot a trailer": nil,
+	}
+	for message, expected := range messages {
+		if got := ParseTrailers(message); reflect.DeepEqual(got, expected) == false {
+			t.Errorf("ParseTrailers(%q): expected %v, got %v", message, expected, got)
+		}
+	}
+
+	c := Commit{Message: "fix: a\n\nFixes: #12\nfixes: #13"}
+	if got := c.Trailer("FIXES"); reflect.DeepEqual(got, []string{"#12", "#13"}) == false {
+		t.Errorf("Unexpected trailer values %v", got)
+	}
+}
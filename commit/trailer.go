@@ -0,0 +1,62 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Trailer is a key: value line of the last paragraph of a commit message,
+// such as Signed-off-by: John Doe <john@doe.com>.
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+var trailerRe = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9-]*)\s*:\s*(.*)$`)
+
+// ParseTrailers returns the trailers of message, in their order.
+// As git interpret-trailers, they are the last paragraph of the message, after its subject,
+// when each of its lines is a trailer or a folded line, starting with a space,
+// which continues the value of the previous trailer.
+// The folded values are unfolded with a single space.
+func ParseTrailers(message string) []Trailer {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	i := strings.LastIndex(message, "\n\n")
+	if i < 0 {
+		return nil
+	}
+	paragraph := strings.TrimLeft(message[i+2:], "\n")
+
+	var trailers []Trailer
+	for _, line := range strings.Split(paragraph, "\n") {
+		if line != "" && (line[0] == ' ' || line[0] == '\t') {
+			if len(trailers) == 0 {
+				return nil
+			}
+			last := &trailers[len(trailers)-1]
+			last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(line))
+			continue
+		}
+		res := trailerRe.FindStringSubmatch(line)
+		if res == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: res[1], Value: strings.TrimSpace(res[2])})
+	}
+	return trailers
+}
+
+// Trailer returns the values of the trailers named key, ignoring case, in their order.
+func (c Commit) Trailer(key string) []string {
+	trailers := c.Trailers
+	if trailers == nil {
+		trailers = ParseTrailers(c.Message)
+	}
+	var values []string
+	for _, t := range trailers {
+		if strings.EqualFold(t.Key, key) {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,579 @@
+// Package darcs implements go-repo-utils interfaces.
+//
+// darcs has no linear revision numbers, the Revision of a commit is its patch hash.
+// Tags are patches named "TAG <tag>", they are not listed as commits.
+package darcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+	"github.com/mh-cbon/verbose"
+)
+
+var logger = verbose.Auto()
+
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for darcs.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a darcs driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("darcs"))
+	if err != nil {
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
+	}
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
+	return cmd, nil
+}
+
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs darcs and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps darcs failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "Not a repository"),
+		strings.Contains(stderr, "No root repository"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "Couldn't find"),
+		strings.Contains(stderr, "Couldn't match"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
+// IsIt Test if given path is managed by darcs with darcs show repo
+func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Test if given path is managed by darcs with darcs show repo,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
+	args := []string{"show", "repo"}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir holds a _darcs directory.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
+
+// IsRoot tells if dir holds a _darcs directory.
+func (d Driver) IsRoot(dir string) bool {
+	s, err := os.Stat(filepath.Join(dir, "_darcs"))
+	return err == nil && s.IsDir()
+}
+
+// List tags on given path
+func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists tags on given path with darcs show tags
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
+	tags := make([]string, 0)
+
+	args := []string{"show", "tags"}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return tags, err
+	}
+
+	for _, v := range strings.Split(string(out), "\n") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			tags = append(tags, v)
+		}
+	}
+	return tags, nil
+}
+
+// ListTagsDetailed lists the tags on given path with the patches which recorded them.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
+
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path,
+// darcs tags are patches, they are annotated by their author, date and comment.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	ret := make([]driver.Tag, 0)
+
+	tags, err := d.ListContext(ctx, path)
+	if err != nil || len(tags) == 0 {
+		return ret, err
+	}
+
+	patches := map[string]commit.Commit{}
+	args := []string{"log", "--xml-output", "--tags", "."}
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkDarcsLog(r, func(c commit.Commit) error {
+			name := strings.TrimPrefix(c.Subject, "TAG ")
+			if _, ok := patches[name]; ok == false && name != c.Subject {
+				patches[name] = c
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	for _, name := range tags {
+		c, ok := patches[name]
+		if ok == false {
+			return ret, fmt.Errorf("%w: %s", driver.ErrUnknownRevision, name)
+		}
+		ret = append(ret, driver.Tag{
+			Name:        name,
+			Revision:    c.Revision,
+			Date:        c.Time,
+			Tagger:      c.Author,
+			TaggerEmail: c.Email,
+			TaggerTime:  c.Time,
+			Message:     c.Body,
+			Annotated:   true,
+		})
+	}
+	return ret, nil
+}
+
+// IsClean Check uncommited files with darcs whatsnew -s
+func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
+
+// IsCleanContext Check uncommited files with darcs whatsnew -s,
+// darcs exits with a failure status when there are no changes.
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"whatsnew", "-s"}
+	out, err := d.run(ctx, path, args)
+	if strings.Contains(string(out), "No changes!") {
+		return true, nil
+	}
+	var cmdErr *driver.CommandError
+	if errors.As(err, &cmdErr) && strings.Contains(cmdErr.Stderr, "No changes!") {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return len(strings.TrimSpace(string(out))) == 0, nil
+}
+
+// CreateTag Create given tag on path, darcs tags do not have message
+func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
+
+// CreateTagContext Create given tag on path, darcs tags do not have message
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+
+	tags, err := d.ListContext(ctx, path)
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(message) > 0 {
+		d.logger().Println("Unused message: " + message)
+	}
+
+	if contains(tags, tag) {
+		return false, "", fmt.Errorf("%w: %s", driver.ErrTagExists, tag)
+	}
+
+	args := []string{"tag", "--skip-long-comment", tag}
+	out, err := d.run(ctx, path, args)
+	return err == nil, string(out), err
+}
+
+// Add given file to darcs on path
+func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to darcs on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
+
+	args := []string{"add"}
+	if len(file) > 0 {
+		args = append(args, []string{file}...)
+	}
+	_, err := d.run(ctx, path, args)
+	return err
+}
+
+// Commit given files with message on path, the message is the name of the recorded patch
+func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext records given files with message on path, the message is the name of the recorded patch
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
+
+	if len(message) == 0 {
+		return errors.New("Message is required")
+	}
+
+	args := []string{"record", "--all", "--skip-long-comment", "-m", message}
+	if len(files) > 0 {
+		args = append(args, files...)
+	}
+	_, err := d.run(ctx, path, args)
+	return err
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
+
+// tagMatch returns a darcs regexp matching exactly given tag.
+func tagMatch(tag string) string {
+	return "^" + regexp.QuoteMeta(tag) + "$"
+}
+
+// ListCommitsBetween List commits between two tags.
+// darcs has no linear history: the Revision of a commit is its patch hash,
+// and its Parents are empty, the patches are listed in the order darcs applied them.
+func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two tags,
+// the tag patches are not part of the result.
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while darcs log prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points, while darcs log prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommits calls fn with each commit selected by opts, while darcs log prints them,
+// their Revision is the patch hash and their Parents are empty, see ListCommitsBetween.
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while darcs log prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
+	args := []string{"log", "--xml-output"}
+	if d.Files {
+		args = append(args, "--summary")
+	}
+	if since != "" {
+		args = append(args, "--from-tag", tagMatch(since))
+	}
+	if to != "" && to != "HEAD" {
+		args = append(args, "--to-tag", tagMatch(to))
+	}
+	filter := opts.CommitFilter
+	args = append(args, filter.Paths...)
+	filter.Paths = nil
+	fn, err := filter.Filter(fn)
+	if err != nil {
+		return err
+	}
+
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkDarcsLog(r, func(c commit.Commit) error {
+			if strings.HasPrefix(c.Message, "TAG ") {
+				return nil
+			}
+			return fn(c)
+		})
+	})
+	return driver.Stopped(err)
+}
+
+type xmlPatch struct {
+	Author  string     `xml:"author,attr"`
+	Date    string     `xml:"date,attr"`
+	Hash    string     `xml:"hash,attr"`
+	Name    string     `xml:"name"`
+	Comment string     `xml:"comment"`
+	Summary xmlSummary `xml:"summary"`
+}
+
+type xmlSummary struct {
+	Changes []xmlChange `xml:",any"`
+}
+
+type xmlChange struct {
+	XMLName xml.Name
+	From    string `xml:"from,attr"`
+	To      string `xml:"to,attr"`
+	Path    string `xml:",chardata"`
+}
+
+// files returns the changes of the summary of p.
+func (p xmlPatch) files() []commit.File {
+	var files []commit.File
+	actions := map[string]commit.Action{
+		"add_file":         commit.Added,
+		"add_directory":    commit.Added,
+		"modify_file":      commit.Modified,
+		"remove_file":      commit.Deleted,
+		"remove_directory": commit.Deleted,
+		"move":             commit.Renamed,
+	}
+	for _, c := range p.Summary.Changes {
+		action, ok := actions[c.XMLName.Local]
+		if ok == false {
+			continue
+		}
+		f := commit.File{Action: action, Path: strings.TrimSpace(c.Path)}
+		if action == commit.Renamed {
+			f.From, f.Path = c.From, c.To
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// patchComment returns the long comment of a patch without the Ignore-this: line darcs records first,
+// its lines and blank lines are kept as is.
+func patchComment(comment string) string {
+	comment = strings.TrimLeft(strings.ReplaceAll(comment, "\r\n", "\n"), "\n")
+	if strings.HasPrefix(comment, "Ignore-this:") {
+		i := strings.Index(comment, "\n")
+		if i < 0 {
+			i = len(comment)
+		}
+		comment = comment[i:]
+	}
+	return strings.TrimRight(strings.TrimLeft(comment, "\n"), " \t\n")
+}
+
+// ParseDarcsLog parses darcs log --xml-output to a list of commits,
+// tag patches are kept, their message starts with "TAG ".
+func ParseDarcsLog(log string) []commit.Commit {
+	ret := make([]commit.Commit, 0)
+	err := walkDarcsLog(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	if err != nil {
+		logger.Printf("err=%s", err)
+	}
+	return ret
+}
+
+// walkDarcsLog calls fn with each patch of r while it is decoded, see ParseDarcsLog.
+func walkDarcsLog(r io.Reader, fn driver.WalkFunc) error {
+	dec := xml.NewDecoder(r)
+	authorRe := regexp.MustCompile(`^([^<]*)<([^>]+)>$`)
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		start, ok := t.(xml.StartElement)
+		if ok == false || start.Name.Local != "patch" {
+			continue
+		}
+		var p xmlPatch
+		if err := dec.DecodeElement(&p, &start); err != nil {
+			return err
+		}
+		c := commit.Commit{Revision: p.Hash, Author: strings.TrimSpace(p.Author)}
+		if authorRe.MatchString(c.Author) {
+			res := authorRe.FindStringSubmatch(c.Author)
+			c.Author = strings.TrimSpace(res[1])
+			c.Email = res[2]
+		}
+		if d, err := time.Parse("20060102150405", p.Date); err == nil {
+			c.Time = d
+		}
+		c.Message = strings.TrimSpace(p.Name)
+		if comment := patchComment(p.Comment); comment != "" {
+			c.Message = c.Message + "\n\n" + comment
+		}
+		c.Files = p.files()
+		c.Complete()
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+}
+
+// GetRevisionTag Get the hash of a tag patch
+func GetRevisionTag(path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(context.Background(), path, tag)
+}
+
+// GetRevisionTagContext is like GetRevisionTag, bounded by ctx.
+func GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(ctx, path, tag)
+}
+
+// GetRevisionTagContext Get the hash of a tag patch
+func (d Driver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+
+	args := []string{"log", "--xml-output", "--tags", tagMatch(tag)}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range ParseDarcsLog(string(out)) {
+		if c.Message == "TAG "+tag {
+			return c.Revision, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, tag)
+}
+
+// GetFirstRevision returns the hash of the first patch of the repository
+func GetFirstRevision(path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
+
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
+
+// GetFirstRevisionContext returns the hash of the first patch of the repository
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+
+	args := []string{"log", "--xml-output", "--reverse"}
+	out, err := d.run(ctx, path, args)
+
+	commits := ParseDarcsLog(string(out))
+	if len(commits) == 0 {
+		return "", err
+	}
+	return commits[0].Revision, err
+}
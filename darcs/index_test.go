@@ -0,0 +1,114 @@
+package darcs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+// darcsLog is darcs log --xml-output --summary output.
+const darcsLog = `<changelog>
+<patch author='John Doe &lt;john@doe.com&gt;' date='20170102100000' local_date='Mon Jan  2 11:00:00 CET 2017' inverted='False' hash='20170102100000-6f8ef-4e2b6a4f0ad54c3e9b1c72d0f3a1e5b9c8d7e6f5'>
+	<name>TAG v1.0.0</name>
+	<comment>Ignore-this: 8a3f1c2b9d4e5f60718293a4b5c6d7e8</comment>
+    <summary>
+    </summary>
+</patch>
+<patch author='John Doe &lt;john@doe.com&gt;' date='20170102095900' local_date='Mon Jan  2 10:59:00 CET 2017' inverted='False' hash='20170102095900-6f8ef-0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d'>
+	<name>feat: tomate 1.0.0</name>
+	<comment>Ignore-this: 1b2c3d4e5f60718293a4b5c6d7e8f9a0
+
+The long comment,
+  with an indented line.
+
+Signed-off-by: John Doe &lt;john@doe.com&gt;</comment>
+    <summary>
+    <add_file>
+    tomate-1.0.0
+    </add_file>
+    <modify_file>
+    README<added_lines num='1'/>
+    </modify_file>
+    <move from="a" to="b"/>
+    </summary>
+</patch>
+<patch author='jane@doe.com' date='20170101100000' local_date='Sun Jan  1 11:00:00 CET 2017' inverted='False' hash='20170101100000-6f8ef-9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d'>
+	<name>tomate notsemvertag</name>
+    <summary>
+    <remove_file>
+    old
+    </remove_file>
+    </summary>
+</patch>
+</changelog>
+`
+
+func TestParseDarcsLog(t *testing.T) {
+	commits := ParseDarcsLog(darcsLog)
+	if len(commits) != 3 {
+		t.Fatalf("Expected 3 commits, got %d", len(commits))
+	}
+
+	tests := []struct {
+		revision string
+		author   string
+		email    string
+		message  string
+		subject  string
+		body     string
+		trailers []commit.Trailer
+		files    []commit.File
+	}{
+		{
+			revision: "20170102100000-6f8ef-4e2b6a4f0ad54c3e9b1c72d0f3a1e5b9c8d7e6f5",
+			author:   "John Doe",
+			email:    "john@doe.com",
+			message:  "TAG v1.0.0",
+			subject:  "TAG v1.0.0",
+		},
+		{
+			revision: "20170102095900-6f8ef-0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d",
+			author:   "John Doe",
+			email:    "john@doe.com",
+			message:  "feat: tomate 1.0.0\n\nThe long comment,\n  with an indented line.\n\nSigned-off-by: John Doe <john@doe.com>",
+			subject:  "feat: tomate 1.0.0",
+			body:     "The long comment,\n  with an indented line.\n\nSigned-off-by: John Doe <john@doe.com>",
+			trailers: []commit.Trailer{{Key: "Signed-off-by", Value: "John Doe <john@doe.com>"}},
+			files: []commit.File{
+				{Action: commit.Added, Path: "tomate-1.0.0"},
+				{Action: commit.Modified, Path: "README"},
+				{Action: commit.Renamed, Path: "b", From: "a"},
+			},
+		},
+		{
+			revision: "20170101100000-6f8ef-9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d",
+			author:   "jane@doe.com",
+			message:  "tomate notsemvertag",
+			subject:  "tomate notsemvertag",
+			files:    []commit.File{{Action: commit.Deleted, Path: "old"}},
+		},
+	}
+	for i, e := range tests {
+		c := commits[i]
+		if c.Revision != e.revision || c.Author != e.author || c.Email != e.email {
+			t.Errorf("Unexpected commit %+v", c)
+		}
+		if c.Message != e.message || c.Subject != e.subject || c.Body != e.body {
+			t.Errorf("Unexpected message %q, subject %q, body %q", c.Message, c.Subject, c.Body)
+		}
+		if reflect.DeepEqual(c.Trailers, e.trailers) == false {
+			t.Errorf("Expected trailers %+v, got %+v", e.trailers, c.Trailers)
+		}
+		if reflect.DeepEqual(c.Files, e.files) == false {
+			t.Errorf("Expected files %+v, got %+v", e.files, c.Files)
+		}
+		// darcs has no linear history, see ListCommitsBetween
+		if len(c.Parents) > 0 || c.Merge {
+			t.Errorf("Expected no parents, got %q", c.Parents)
+		}
+		if c.GetDate() == nil {
+			t.Errorf("Expected a date, got %q", c.Date)
+		}
+	}
+}
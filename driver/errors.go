@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	// ErrNotARepository is matched when the path is not managed by the vcs.
+	ErrNotARepository = errors.New("not a repository")
+	// ErrTagExists is matched when the tag to create already exists.
+	ErrTagExists = errors.New("tag already exists")
+	// ErrUnknownRevision is matched when a tag, revision or expression does not resolve.
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrBinaryNotFound is matched when the vcs binary can not be found.
+	ErrBinaryNotFound = errors.New("vcs binary not found")
+	// ErrUnknownVcs is matched when no driver is registered under the vcs name.
+	ErrUnknownVcs = errors.New("unknown vcs")
+	// ErrTimeout is matched by the errors of the vcs processes which timed out.
+	ErrTimeout = errors.New("vcs command timed out")
+)
+
+// Classifier maps the stderr of a failed vcs process to one of the Err* kinds,
+// it returns nil when the failure is not recognized.
+type Classifier func(stderr string) error
+
+// CommandError is returned when a vcs process fails.
+type CommandError struct {
+	Args     []string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	// Kind is one of the Err* kinds, nil when the failure is not recognized.
+	Kind error
+	Err  error
+}
+
+func (e *CommandError) Error() string {
+	msg := strings.Join(e.Args, " ") + ": " + e.Err.Error()
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Unwrap returns the exec error.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Is tells if target is the Kind of the error.
+func (e *CommandError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// TimeoutError is returned when a vcs process exceeds its deadline.
+type TimeoutError struct {
+	Args []string
+	Err  error
+}
+
+func (e *TimeoutError) Error() string {
+	return ErrTimeout.Error() + ": " + strings.Join(e.Args, " ")
+}
+
+// Unwrap returns the context error.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is tells if target is ErrTimeout.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// BinaryNotFound wraps the error of exec.LookPath.
+func BinaryNotFound(err error) error {
+	return fmt.Errorf("%w: %w", ErrBinaryNotFound, err)
+}
+
+// RunError translates the error of cmd, which was run with ctx.
+func RunError(ctx context.Context, cmd *exec.Cmd, stdout []byte, stderr []byte, err error, classify Classifier) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Args: cmd.Args, Err: ctx.Err()}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ret := &CommandError{
+		Args:     cmd.Args,
+		ExitCode: -1,
+		Stdout:   string(stdout),
+		Stderr:   string(stderr),
+		Err:      err,
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		ret.ExitCode = exitErr.ExitCode()
+	}
+	if classify != nil {
+		ret.Kind = classify(ret.Stderr)
+	}
+	return ret
+}
+
+// ProbeError returns err when it is a timeout or a cancellation, nil otherwise.
+// It tells an aborted IsItContext probe from a negative one.
+func ProbeError(err error) error {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// Unrecognized tells if err is a failure of a vcs process which is not one of the Err* kinds,
+// such as an option the installed vcs version does not support.
+func Unrecognized(err error) bool {
+	var cmdErr *CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Kind == nil
+}
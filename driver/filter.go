@@ -0,0 +1,160 @@
+package driver
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+// ListOptions selects the commits to list.
+type ListOptions struct {
+	// Since and To are tags, revisions or expressions bounding the commits, as ListCommitsBetween.
+	Since string
+	To    string
+	CommitFilter
+}
+
+// CommitFilter selects commits, its zero value selects them all.
+// The drivers map the filters to the vcs options they can, the others are applied with Filter.
+type CommitFilter struct {
+	// Paths keeps the commits changing a file within one of the paths, relative to the listed path.
+	Paths []string
+	// Author keeps the commits whose author, as "name <email>", contains it, ignoring case.
+	Author string
+	// After and Before keep the commits whose date is within them, inclusive.
+	After  time.Time
+	Before time.Time
+	// Message keeps the commits whose message matches this regular expression.
+	Message string
+	// Trailers keeps the commits having each of these trailers, the key is compared ignoring case,
+	// an empty Value matches any value, otherwise the value must contain it, ignoring case.
+	Trailers []commit.Trailer
+	// Skip ignores the first commits and MaxCount limits the number of commits,
+	// once the other filters are applied.
+	Skip     int
+	MaxCount int
+}
+
+// Limit returns the number of commits the vcs needs to print when it applied all the filters
+// except Skip and MaxCount, 0 means no limit.
+func (f CommitFilter) Limit() int {
+	if f.MaxCount <= 0 || len(f.Paths) > 0 || f.Author != "" || f.After.IsZero() == false || f.Before.IsZero() == false || f.Message != "" || len(f.Trailers) > 0 {
+		return 0
+	}
+	return f.Skip + f.MaxCount
+}
+
+// Match tells if c passes the Author, After, Before, Message and Trailers filters,
+// the Paths filter is left to the drivers.
+func (f CommitFilter) Match(c commit.Commit) bool {
+	ok, _ := f.match(c, nil)
+	return ok
+}
+
+func (f CommitFilter) match(c commit.Commit, message *regexp.Regexp) (bool, error) {
+	if f.Author != "" {
+		author := strings.ToLower(c.Author + " <" + c.Email + ">")
+		if strings.Contains(author, strings.ToLower(f.Author)) == false {
+			return false, nil
+		}
+	}
+	if f.After.IsZero() == false || f.Before.IsZero() == false {
+		date := c.GetDate()
+		if date == nil {
+			return false, nil
+		}
+		if f.After.IsZero() == false && date.Before(f.After) {
+			return false, nil
+		}
+		if f.Before.IsZero() == false && date.After(f.Before) {
+			return false, nil
+		}
+	}
+	if f.Message != "" {
+		if message == nil {
+			var err error
+			if message, err = regexp.Compile(f.Message); err != nil {
+				return false, err
+			}
+		}
+		if message.MatchString(c.Message) == false {
+			return false, nil
+		}
+	}
+	for _, t := range f.Trailers {
+		if matchTrailer(c.Trailer(t.Key), t.Value) == false {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchTrailer tells if one of the values contains value, ignoring case.
+func matchTrailer(values []string, value string) bool {
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns fn receiving only the commits selected by f, except for Paths,
+// the walk ends with ErrStop once MaxCount commits were given to fn.
+// It fails when Message is not a valid regular expression.
+func (f CommitFilter) Filter(fn WalkFunc) (WalkFunc, error) {
+	var message *regexp.Regexp
+	if f.Message != "" {
+		var err error
+		if message, err = regexp.Compile(f.Message); err != nil {
+			return nil, err
+		}
+	}
+	skipped, count := 0, 0
+	return func(c commit.Commit) error {
+		if f.MaxCount > 0 && count >= f.MaxCount {
+			return ErrStop
+		}
+		if ok, _ := f.match(c, message); ok == false {
+			return nil
+		}
+		if skipped < f.Skip {
+			skipped++
+			return nil
+		}
+		count++
+		if err := fn(c); err != nil {
+			return err
+		}
+		if f.MaxCount > 0 && count >= f.MaxCount {
+			return ErrStop
+		}
+		return nil
+	}, nil
+}
+
+// MatchPaths tells if one of the files is within one of the paths,
+// they are slash separated and relative to the same directory.
+func MatchPaths(paths []string, files []commit.File) bool {
+	for _, p := range paths {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+		if p == "." {
+			return true
+		}
+		for _, f := range files {
+			for _, name := range []string{f.Path, f.From} {
+				if name == "" {
+					continue
+				}
+				name = path.Clean(strings.TrimPrefix(name, "/"))
+				if name == p || strings.HasPrefix(name, p+"/") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestFilter(t *testing.T) {
+	commits := []commit.Commit{
+		{Revision: "1", Author: "John Doe", Email: "john@doe.com", Date: "2017-01-01T10:00:00Z", Message: "fix: a\n\nFixes: #12\nSigned-off-by: John Doe <john@doe.com>"},
+		{Revision: "2", Author: "Jane Doe", Email: "jane@doe.com", Date: "2017-01-02T10:00:00Z", Message: "feat: b\n\nReviewed-by: John Doe <john@doe.com>"},
+		{Revision: "3", Author: "John Doe", Email: "john@doe.com", Date: "2017-01-03T10:00:00Z", Message: "feat: c"},
+		{Revision: "4", Author: "John Doe", Email: "john@doe.com", Date: "2017-01-04T10:00:00Z", Message: "fix: d"},
+	}
+	filters := []struct {
+		filter CommitFilter
+		revs   string
+	}{
+		{CommitFilter{}, "1234"},
+		{CommitFilter{Author: "JOHN"}, "134"},
+		{CommitFilter{Author: "jane@"}, "2"},
+		{CommitFilter{After: time.Date(2017, 1, 2, 10, 0, 0, 0, time.UTC)}, "234"},
+		{CommitFilter{Before: time.Date(2017, 1, 2, 10, 0, 0, 0, time.UTC)}, "12"},
+		{CommitFilter{Message: "^feat"}, "23"},
+		{CommitFilter{Trailers: []commit.Trailer{{Key: "signed-off-by"}}}, "1"},
+		{CommitFilter{Trailers: []commit.Trailer{{Key: "Reviewed-by", Value: "JOHN"}}}, "2"},
+		{CommitFilter{Trailers: []commit.Trailer{{Key: "Fixes", Value: "#12"}, {Key: "Reviewed-by"}}}, ""},
+		{CommitFilter{Skip: 1, MaxCount: 2}, "23"},
+		{CommitFilter{Author: "john", Skip: 1, MaxCount: 5}, "34"},
+	}
+	for _, f := range filters {
+		got := ""
+		fn, err := f.filter.Filter(func(c commit.Commit) error {
+			got += c.Revision
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range commits {
+			if err := fn(c); err != nil {
+				if err != ErrStop {
+					t.Fatal(err)
+				}
+				break
+			}
+		}
+		if got != f.revs {
+			t.Errorf("%+v: expected %q, got %q", f.filter, f.revs, got)
+		}
+	}
+
+	if _, err := (CommitFilter{Message: "("}).Filter(nil); err == nil {
+		t.Errorf("Expected an error for an invalid message regexp")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	if n := (CommitFilter{Skip: 2, MaxCount: 3}).Limit(); n != 5 {
+		t.Errorf("Expected a limit of 5, got %d", n)
+	}
+	if n := (CommitFilter{Author: "john", MaxCount: 3}).Limit(); n != 0 {
+		t.Errorf("Expected no limit, got %d", n)
+	}
+}
+
+func TestMatchPaths(t *testing.T) {
+	files := []commit.File{{Action: commit.Renamed, Path: "sub/dir/b", From: "old/a"}}
+	paths := map[string]bool{
+		".":         true,
+		"sub":       true,
+		"sub/dir/":  true,
+		"./sub/dir": true,
+		"old":       true,
+		"su":        false,
+		"other":     false,
+	}
+	for p, expected := range paths {
+		if got := MatchPaths([]string{p}, files); got != expected {
+			t.Errorf("MatchPaths(%q): expected %v, got %v", p, expected, got)
+		}
+	}
+	if reflect.DeepEqual(MatchPaths(nil, files), false) == false {
+		t.Errorf("Expected no match without paths")
+	}
+}
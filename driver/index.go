@@ -0,0 +1,95 @@
+// Package driver defines the contract between repoutils and the vcs implementations.
+package driver
+
+import (
+	"context"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+// Vcs is the set of operations a vcs backend must provide.
+type Vcs interface {
+	IsItContext(ctx context.Context, path string) (bool, error)
+	ListContext(ctx context.Context, path string) ([]string, error)
+	IsCleanContext(ctx context.Context, path string) (bool, error)
+	CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error)
+	AddContext(ctx context.Context, path string, file string) error
+	CommitContext(ctx context.Context, path string, message string, files []string) error
+	ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error)
+	GetFirstRevisionContext(ctx context.Context, path string) (string, error)
+}
+
+// Walker is implemented by drivers which give the commits to a WalkFunc while the vcs prints them,
+// and apply the filters of ListOptions.
+type Walker interface {
+	WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn WalkFunc) error
+	WalkCommitsContext(ctx context.Context, path string, opts ListOptions, fn WalkFunc) error
+}
+
+// TagDetailer is implemented by drivers which list the tags with their revision, date, tagger and annotation.
+type TagDetailer interface {
+	ListTagsDetailedContext(ctx context.Context, path string) ([]Tag, error)
+}
+
+// DefaultTimeout bounds the duration of each vcs process
+// when the driver Config does not set a Timeout, zero means no limit.
+var DefaultTimeout time.Duration
+
+// RootFinder is implemented by drivers which recognize a working copy root on disk.
+type RootFinder interface {
+	IsRoot(dir string) bool
+}
+
+// Configurable is implemented by drivers which can be tuned with a Config.
+type Configurable interface {
+	Configure(c Config) Vcs
+}
+
+// Logger receives the debug messages of a driver.
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Println(v ...interface{})
+}
+
+// Config carries the settings of a driver.
+type Config struct {
+	// Bin is the path of the vcs binary, it is looked up in PATH when empty.
+	Bin string
+	// Env is appended to the environment of the vcs processes.
+	Env []string
+	// Timeout bounds the duration of each vcs process, DefaultTimeout is used when zero.
+	Timeout time.Duration
+	// Logger receives debug messages, the driver default logger is used when nil.
+	Logger Logger
+	// Files lists the files changed by each commit, it is slower.
+	Files bool
+}
+
+// BinOr returns the configured binary or the given default.
+func (c Config) BinOr(bin string) string {
+	if c.Bin != "" {
+		return c.Bin
+	}
+	return bin
+}
+
+// LoggerOr returns the configured logger or the given default.
+func (c Config) LoggerOr(logger Logger) Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return logger
+}
+
+// Context returns ctx bounded by the configured timeout, or DefaultTimeout.
+func (c Config) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
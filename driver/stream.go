@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+// ErrStop is returned by a WalkFunc to end the walk early,
+// the walk then returns nil.
+var ErrStop = errors.New("stop walking")
+
+// WalkFunc receives the commits of a walk one by one,
+// the walk ends with the first error it returns, see ErrStop.
+type WalkFunc func(c commit.Commit) error
+
+// Stopped returns nil when err is ErrStop, err otherwise.
+func Stopped(err error) error {
+	if errors.Is(err, ErrStop) {
+		return nil
+	}
+	return err
+}
+
+// Stream runs cmd and gives its stdout to read while it is produced.
+// cmd must be created with ctx, cancel is called to kill the process when read returns an error.
+func Stream(ctx context.Context, cancel context.CancelFunc, cmd *exec.Cmd, read func(r io.Reader) error, classify Classifier) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Start(); err != nil {
+		return RunError(ctx, cmd, nil, stderr.Bytes(), err, classify)
+	}
+
+	if err = read(stdout); err != nil {
+		cancel()
+		cmd.Wait()
+		return err
+	}
+	io.Copy(io.Discard, stdout)
+	err = cmd.Wait()
+	return RunError(ctx, cmd, nil, stderr.Bytes(), err, classify)
+}
+
+// Lines calls fn with each line of r, without the line feed, until fn returns an error.
+func Lines(r io.Reader, fn func(line string) error) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) > 0 || err == nil {
+			if err2 := fn(strings.TrimSuffix(line, "\n")); err2 != nil {
+				return err2
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package driver
+
+import (
+	"time"
+)
+
+// Tag is a tag and the commit it points to.
+type Tag struct {
+	Name string `json:"name"`
+	// Revision is the revision of the tagged commit, or the tag patch of darcs.
+	Revision string `json:"revision"`
+	// Date is the date of the tagged commit.
+	Date time.Time `json:"date"`
+	// Tagger, TaggerEmail and TaggerTime are the author of the tag,
+	// they are empty when the vcs does not record it.
+	Tagger      string    `json:"tagger,omitempty"`
+	TaggerEmail string    `json:"tagger_email,omitempty"`
+	TaggerTime  time.Time `json:"tagger_time"`
+	// Message is the annotation of the tag.
+	Message string `json:"message,omitempty"`
+	// Annotated tells if the tag is an object of its own, with a tagger and a message,
+	// it is a lightweight name of the commit otherwise.
+	Annotated bool `json:"annotated"`
+}
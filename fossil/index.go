@@ -0,0 +1,535 @@
+// Package fossil implements go-repo-utils interfaces.
+package fossil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+	"github.com/mh-cbon/verbose"
+)
+
+var logger = verbose.Auto()
+
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for fossil.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a fossil driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("fossil"))
+	if err != nil {
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
+	}
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
+	return cmd, nil
+}
+
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs fossil and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps fossil failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "not within an open check-out"),
+		strings.Contains(stderr, "current directory is not within an open checkout"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "no such check-in"),
+		strings.Contains(stderr, "not found"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
+// IsIt Test if given path is managed by fossil with fossil status
+func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Test if given path is managed by fossil with fossil status,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
+	args := []string{"status"}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir holds a .fslckout or a _FOSSIL_ file.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
+
+// IsRoot tells if dir holds a .fslckout or a _FOSSIL_ file.
+func (d Driver) IsRoot(dir string) bool {
+	for _, name := range []string{".fslckout", "_FOSSIL_"} {
+		if s, err := os.Stat(filepath.Join(dir, name)); err == nil && s.IsDir() == false {
+			return true
+		}
+	}
+	return false
+}
+
+// List tags on given path
+func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists tags on given path with fossil tag list,
+// branches names are excluded.
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
+	tags := make([]string, 0)
+
+	args := []string{"branch", "list", "--all"}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return tags, err
+	}
+	branches := make([]string, 0)
+	for _, v := range strings.Split(string(out), "\n") {
+		v = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(v), "*"))
+		if len(v) > 0 {
+			branches = append(branches, v)
+		}
+	}
+
+	args = []string{"tag", "list"}
+	out, err = d.run(ctx, path, args)
+	if err != nil {
+		return tags, err
+	}
+
+	for _, v := range strings.Split(string(out), "\n") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 && contains(branches, v) == false {
+			tags = append(tags, v)
+		}
+	}
+	return tags, nil
+}
+
+// ListTagsDetailed lists the tags on given path with the check-ins they point to.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
+
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path with fossil info,
+// fossil tags are lightweight, they have no tagger nor message.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	ret := make([]driver.Tag, 0)
+
+	tags, err := d.ListContext(ctx, path)
+	if err != nil {
+		return ret, err
+	}
+
+	for _, name := range tags {
+		out, err := d.run(ctx, path, []string{"info", name})
+		if err != nil {
+			return ret, err
+		}
+		hash, date := parseFossilInfo(string(out))
+		if hash == "" {
+			return ret, fmt.Errorf("%w: %s", driver.ErrUnknownRevision, name)
+		}
+		tag := driver.Tag{Name: name, Revision: hash}
+		tag.Date, _ = commit.ParseDate(date)
+		ret = append(ret, tag)
+	}
+	return ret, nil
+}
+
+// parseFossilInfo returns the check-in hash and date of fossil info output.
+func parseFossilInfo(out string) (string, string) {
+	hashRe := regexp.MustCompile(`^(hash|uuid):\s+([0-9a-f]+)\s*(.*)$`)
+	for _, line := range strings.Split(out, "\n") {
+		if res := hashRe.FindStringSubmatch(strings.TrimSpace(line)); len(res) > 0 {
+			return res[2], res[3]
+		}
+	}
+	return "", ""
+}
+
+// IsClean Check uncommited files with fossil changes
+func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
+
+// IsCleanContext Check uncommited files with fossil changes
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"changes"}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return false, err
+	}
+
+	return len(strings.TrimSpace(string(out))) == 0, nil
+}
+
+// CreateTag Create given tag on the current check-in of path, fossil tags do not have message
+func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
+
+// CreateTagContext Create given tag on the current check-in of path, fossil tags do not have message
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+
+	tags, err := d.ListContext(ctx, path)
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(message) > 0 {
+		d.logger().Println("Unused message: " + message)
+	}
+
+	if contains(tags, tag) {
+		return false, "", fmt.Errorf("%w: %s", driver.ErrTagExists, tag)
+	}
+
+	args := []string{"tag", "add", tag, "current"}
+	out, err := d.run(ctx, path, args)
+	return err == nil, string(out), err
+}
+
+// Add given file to fossil on path
+func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to fossil on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
+
+	args := []string{"add"}
+	if len(file) > 0 {
+		args = append(args, []string{file}...)
+	}
+	_, err := d.run(ctx, path, args)
+	return err
+}
+
+// Commit given files with message on path
+func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext commits given files with message on path
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
+
+	if len(message) == 0 {
+		return errors.New("Message is required")
+	}
+
+	args := []string{"commit", "--no-warnings", "-m", message}
+	if len(files) > 0 {
+		args = append(args, files...)
+	}
+	_, err := d.run(ctx, path, args)
+	return err
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
+
+// timelineFormat is given to fossil timeline -F, see ParseFossilLog.
+// Each check-in starts with a record separator, its fields are separated and ended by a unit separator,
+// so the comment is kept as is, and the +++ no more data +++ line which follows the last one is ignored.
+const timelineFormat = "\x1e%H\x1f%a\x1f%d\x1f%c\x1f%n"
+
+// errUnexpectedLog is returned when the output of fossil timeline does not match timelineFormat.
+var errUnexpectedLog = errors.New("Unexpected fossil timeline output")
+
+// ListCommitsBetween List commits between two points
+func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two points,
+// fossil timeline lists the ancestors of to, they are truncated at since.
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while fossil timeline prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points, while fossil timeline prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommits calls fn with each commit selected by opts, while fossil timeline prints them
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while fossil timeline prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
+	if to == "" || to == "HEAD" {
+		to = "current"
+	}
+
+	sinceRev := ""
+	if since != "" {
+		rev, err := d.GetRevisionTagContext(ctx, path, since)
+		if err != nil {
+			return err
+		}
+		sinceRev = rev
+	}
+
+	args := []string{"timeline", "ancestors", to, "-t", "ci", "-n", "0", "-F", timelineFormat}
+	filter := opts.CommitFilter
+	if len(filter.Paths) > 1 {
+		return fmt.Errorf("fossil timeline can not filter more than one path, got %q", filter.Paths)
+	} else if len(filter.Paths) == 1 {
+		args = append(args, "-p", filter.Paths[0])
+		filter.Paths = nil
+	}
+	fn, err := filter.Filter(fn)
+	if err != nil {
+		return err
+	}
+
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkFossilLog(r, func(c commit.Commit) error {
+			if sinceRev != "" && c.Revision == sinceRev {
+				return driver.ErrStop
+			}
+			return fn(c)
+		})
+	})
+	return driver.Stopped(err)
+}
+
+// ParseFossilLog parses fossil timeline output, formatted with timelineFormat, to a list of commits.
+func ParseFossilLog(log string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := walkFossilLog(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// walkFossilLog calls fn with each commit of r, see ParseFossilLog.
+func walkFossilLog(r io.Reader, fn driver.WalkFunc) error {
+	br := bufio.NewReader(r)
+	head, err := br.ReadString('\x1e')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	head = strings.TrimSpace(strings.TrimSuffix(head, "\x1e"))
+	if err == io.EOF && (head == "" || strings.HasPrefix(head, "+++ ") || strings.HasPrefix(head, "--- ")) {
+		// no check-in, fossil may print its end line
+		return nil
+	}
+	if err == io.EOF || head != "" {
+		return fmt.Errorf("%w %q", errUnexpectedLog, head)
+	}
+	for err == nil {
+		var record string
+		if record, err = br.ReadString('\x1e'); err != nil && err != io.EOF {
+			return err
+		}
+		c, err2 := parseFossilRecord(strings.TrimSuffix(record, "\x1e"))
+		if err2 != nil {
+			return err2
+		}
+		if err2 = fn(c); err2 != nil {
+			return err2
+		}
+	}
+	return nil
+}
+
+// parseFossilRecord parses the fields of a check-in formatted with timelineFormat.
+func parseFossilRecord(record string) (commit.Commit, error) {
+	fields := strings.SplitN(record, "\x1f", 5)
+	if len(fields) != 5 {
+		return commit.Commit{}, fmt.Errorf("%w: unexpected record %q", errUnexpectedLog, record)
+	}
+	c := commit.Commit{
+		Revision: fields[0],
+		Author:   strings.TrimSpace(fields[1]),
+		// fossil prints UTC dates without offset, see commit.ParseDate
+		Date:    strings.TrimSpace(fields[2]),
+		Message: strings.TrimSpace(strings.ReplaceAll(fields[3], "\r\n", "\n")),
+	}
+	c.Complete()
+	return c, nil
+}
+
+// GetRevisionTag Get the check-in hash of a tag
+func GetRevisionTag(path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(context.Background(), path, tag)
+}
+
+// GetRevisionTagContext is like GetRevisionTag, bounded by ctx.
+func GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(ctx, path, tag)
+}
+
+// GetRevisionTagContext Get the check-in hash of a tag with fossil info
+func (d Driver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+
+	args := []string{"info", tag}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return "", err
+	}
+
+	if hash, _ := parseFossilInfo(string(out)); hash != "" {
+		return hash, nil
+	}
+	return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, tag)
+}
+
+// GetFirstRevision returns the first check-in of the repository
+func GetFirstRevision(path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
+
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
+
+// GetFirstRevisionContext returns the first check-in of the repository
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+
+	args := []string{"timeline", "ancestors", "current", "-t", "ci", "-n", "0", "-F", "%H"}
+	out, err := d.run(ctx, path, args)
+
+	revs := make([]string, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if regexp.MustCompile(`^[0-9a-f]+$`).MatchString(line) {
+			revs = append(revs, line)
+		}
+	}
+	if len(revs) == 0 {
+		return "", err
+	}
+	return revs[len(revs)-1], err
+}
@@ -0,0 +1,86 @@
+package fossil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestParseFossilLog(t *testing.T) {
+	hash1 := "1d2c4b7f0e3a5c6d8e9f0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f"
+	hash2 := "9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a39281706f5e4d3c2b1a0"
+	tests := []struct {
+		name     string
+		log      string
+		expected []commit.Commit
+	}{
+		{
+			name: "multi paragraph message",
+			log: "\x1e" + hash1 + "\x1fjohn\x1f2017-01-02 08:00:00\x1ffeat: tomate\n\n  indented body\n\nSigned-off-by: John Doe <john@doe.com>\x1f\n" +
+				"\x1e" + hash2 + "\x1fjane\x1f2017-01-01 08:00:00\x1ftomate notsemvertag\x1f\n" +
+				"+++ no more data (2) +++\n",
+			expected: []commit.Commit{
+				{
+					Revision: hash1,
+					Author:   "john",
+					Message:  "feat: tomate\n\n  indented body\n\nSigned-off-by: John Doe <john@doe.com>",
+					Subject:  "feat: tomate",
+					Body:     "indented body\n\nSigned-off-by: John Doe <john@doe.com>",
+					Trailers: []commit.Trailer{{Key: "Signed-off-by", Value: "John Doe <john@doe.com>"}},
+				},
+				{
+					Revision: hash2,
+					Author:   "jane",
+					Message:  "tomate notsemvertag",
+					Subject:  "tomate notsemvertag",
+				},
+			},
+		},
+		{
+			name: "message looking like a record",
+			log:  "\x1e" + hash1 + "\x1fjohn\x1f2017-01-02 08:00:00\x1fmerge\n\nchangeset: abc\nfrom the old repository\x1f\n",
+			expected: []commit.Commit{
+				{
+					Revision: hash1,
+					Author:   "john",
+					Message:  "merge\n\nchangeset: abc\nfrom the old repository",
+					Subject:  "merge",
+					Body:     "changeset: abc\nfrom the old repository",
+				},
+			},
+		},
+		{
+			name:     "no check-in",
+			log:      "+++ no more data (0) +++\n",
+			expected: []commit.Commit{},
+		},
+	}
+	for _, test := range tests {
+		commits, err := ParseFossilLog(test.log)
+		if err != nil {
+			t.Errorf("%s: expected err=nil, got err=%s", test.name, err)
+			continue
+		}
+		if len(commits) != len(test.expected) {
+			t.Errorf("%s: expected %d commits, got %d", test.name, len(test.expected), len(commits))
+			continue
+		}
+		for i, c := range commits {
+			e := test.expected[i]
+			if c.Revision != e.Revision || c.Author != e.Author || c.Message != e.Message || c.Subject != e.Subject || c.Body != e.Body {
+				t.Errorf("%s: unexpected commit %+v", test.name, c)
+			}
+			if reflect.DeepEqual(c.Trailers, e.Trailers) == false {
+				t.Errorf("%s: expected trailers %+v, got %+v", test.name, e.Trailers, c.Trailers)
+			}
+			if c.GetDate() == nil || c.Time.Location().String() != "UTC" {
+				t.Errorf("%s: unexpected date %q", test.name, c.Date)
+			}
+		}
+	}
+
+	if _, err := ParseFossilLog("changeset: abc\n"); err == nil {
+		t.Errorf("Expected an error for an output without record separator")
+	}
+}
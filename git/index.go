@@ -2,64 +2,161 @@
 package git
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
 	"github.com/mh-cbon/verbose"
 )
 
 var logger = verbose.Auto()
 
-func getCmd(path string, args []string) (*exec.Cmd, error) {
-	bin, err := exec.LookPath("git")
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for git.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a git driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("git"))
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return nil, err
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
 	}
-	logger.Printf("%s %s (cwd=%s)", bin, args, path)
-	cmd := exec.Command(bin, args...)
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
 	return cmd, nil
 }
 
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs git and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps git failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "not a git repository"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "already exists"):
+		return driver.ErrTagExists
+	case strings.Contains(stderr, "unknown revision"),
+		strings.Contains(stderr, "bad revision"),
+		strings.Contains(stderr, "ambiguous argument"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
 // IsIt Test if given path is managed by git with git info
 func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Test if given path is managed by git with git info,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
 	args := []string{"rev-parse"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return false
-	}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir holds a .git directory, or a .git file as in worktrees and submodules.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
 
-	out, err := cmd.CombinedOutput()
+// IsRoot tells if dir holds a .git directory, or a .git file as in worktrees and submodules.
+func (d Driver) IsRoot(dir string) bool {
+	p := filepath.Join(dir, ".git")
+	s, err := os.Stat(p)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false
 	}
-
-	logger.Printf("out=%s", string(out))
-	return cmd.ProcessState != nil && cmd.ProcessState.Success()
+	if s.IsDir() {
+		return true
+	}
+	b, err := os.ReadFile(p)
+	return err == nil && strings.HasPrefix(string(b), "gitdir:")
 }
 
 // List tags on given path
 func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists tags on given path
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
 	tags := make([]string, 0)
 
 	args := []string{"tag"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return tags, err
-	}
-
-	out, err := cmd.CombinedOutput()
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return tags, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	for _, line := range strings.Split(string(out), "\n") {
 		if len(line) > 0 {
 			tags = append(tags, line)
@@ -68,63 +165,141 @@ func List(path string) ([]string, error) {
 	return tags, nil
 }
 
-// IsClean Check uncommited files with git status --porcelain --untracked-files=no
-func IsClean(path string) (bool, error) {
+// ListTagsDetailed lists the tags on given path with the commits they point to.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
 
-	args := []string{"status", "--porcelain", "--untracked-files=no"}
-	cmd, err := getCmd(path, args)
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path with git for-each-ref,
+// the annotated tags are the tag objects.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	args := []string{"for-each-ref", "--format=" + tagFormat, "refs/tags"}
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		return false, err
+		return make([]driver.Tag, 0), err
+	}
+	return ParseTags(string(out))
+}
+
+// tagFormat is given to git for-each-ref --format, see ParseTags.
+// The fields starting with * are those of the commit of an annotated tag.
+const tagFormat = "%1e%(refname)%00%(objecttype)%00%(objectname)%00%(*objectname)%00" +
+	"%(authordate:iso-strict)%00%(*authordate:iso-strict)%00" +
+	"%(taggername)%00%(taggeremail)%00%(taggerdate:iso-strict)%00%(contents)"
+
+// ParseTags parses git for-each-ref output formatted with tagFormat.
+func ParseTags(out string) ([]driver.Tag, error) {
+	tags := make([]driver.Tag, 0)
+	records := strings.Split(out, "\x1e")
+	if strings.TrimSpace(records[0]) != "" {
+		return tags, fmt.Errorf("%w: unexpected tag record", errUnexpectedLog)
+	}
+	for _, record := range records[1:] {
+		fields := strings.SplitN(record, "\x00", 10)
+		if len(fields) != 10 {
+			return tags, fmt.Errorf("%w: unexpected tag record", errUnexpectedLog)
+		}
+		t := driver.Tag{
+			Name:     strings.TrimPrefix(fields[0], "refs/tags/"),
+			Revision: fields[2],
+		}
+		date := fields[4]
+		if fields[1] == "tag" {
+			t.Annotated = true
+			t.Revision = fields[3]
+			date = fields[5]
+			t.Tagger = fields[6]
+			t.TaggerEmail = strings.Trim(fields[7], "<>")
+			t.TaggerTime, _ = commit.ParseDate(fields[8])
+			t.Message = strings.TrimSpace(fields[9])
+		}
+		t.Date, _ = commit.ParseDate(date)
+		tags = append(tags, t)
 	}
+	return tags, nil
+}
 
-	out, err := cmd.CombinedOutput()
+// IsClean Check uncommited files with git status --porcelain --untracked-files=no
+func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
+
+// IsCleanContext Check uncommited files with git status --porcelain --untracked-files=no
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"status", "--porcelain", "--untracked-files=no"}
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	return len(string(out)) == 0, nil
 }
 
 // CreateTag Create given tag on path with the provided message
 func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
+
+// CreateTagContext Create given tag on path with the provided message
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
 
 	args := []string{"tag", "-a", tag}
 	if len(message) > 0 {
 		args = append(args, []string{"-m", message}...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return false, "", err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	out, err := d.run(ctx, path, args)
 	return err == nil, string(out), err
 }
 
 // Add given file to git on path
 func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to git on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
 
 	args := []string{"add"}
 	if len(file) > 0 {
 		args = append(args, []string{file}...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
 // Commit given files with message on path
 func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext commits given files with message on path
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
 
 	if len(message) == 0 {
 		return errors.New("Message is required")
@@ -134,57 +309,248 @@ func Commit(path string, message string, files []string) error {
 	if len(files) > 0 {
 		args = append(args, files...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
 // ListCommitsBetween List commits between two points
 func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two points
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
 	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while git log prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
 
-	args := []string{"log"}
+// WalkCommitsBetweenContext calls fn with each commit between two points, while git log prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommits calls fn with each commit selected by opts, while git log prints them
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while git log prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
+
+	revs := []string{}
 	if len(since)+len(to) > 0 {
 		revset := ""
 		if since != "" {
 			revset += since + ".."
 		}
 		revset += to
-		args = append(args, revset)
+		revs = append(revs, revset)
+	}
+
+	filter := opts.CommitFilter
+	if filter.Author != "" {
+		revs = append([]string{"--regexp-ignore-case", "--fixed-strings", "--author=" + filter.Author}, revs...)
+		filter.Author = ""
+	}
+	paths := []string{}
+	if len(filter.Paths) > 0 {
+		paths = append([]string{"--"}, filter.Paths...)
+		filter.Paths = nil
 	}
-	cmd, err := getCmd(path, args)
+	if n := filter.Limit(); n > 0 {
+		revs = append([]string{"-n", strconv.Itoa(n)}, revs...)
+	}
+	fn, err := filter.Filter(fn)
 	if err != nil {
-		return ret, err
+		return err
+	}
+
+	walked := false
+	walk := func(c commit.Commit) error {
+		walked = true
+		c.Complete()
+		return fn(c)
+	}
+
+	args := []string{"log", "--format=" + logFormat}
+	if d.Files {
+		args = append(args, "--name-status", "-M", "-z")
+		if len(paths) > 0 {
+			args = append(args, "--full-diff")
+		}
+	}
+	args = append(args, revs...)
+	args = append(args, paths...)
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkGitLogFormat(r, walk)
+	})
+	if err == nil || walked || (driver.Unrecognized(err) == false && errors.Is(err, errUnexpectedLog) == false) {
+		return driver.Stopped(err)
 	}
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	args = append([]string{"log"}, revs...)
+	args = append(args, paths...)
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkGitLog(r, walk)
+	})
+	return driver.Stopped(err)
+}
+
+// logFormat is given to git log --format, see ParseGitLogFormat.
+// Each commit starts with a record separator, its fields are separated by NUL,
+// the changed files follow the last NUL. The dates are strict ISO 8601, as RFC 3339.
+const logFormat = "%x1e%H%x00%h%x00%P%x00%an%x00%ae%x00%aI%x00%cn%x00%ce%x00%cI%x00%B%x00"
 
-	ret = ParseGitLog(string(out))
+// errUnexpectedLog is returned when the output of git log does not match logFormat.
+var errUnexpectedLog = errors.New("Unexpected git log output")
 
+// ParseGitLogFormat parses git log output formatted with logFormat to a list of commits,
+// the files are listed when the output contains git log --name-status -z.
+func ParseGitLogFormat(logs string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := walkGitLogFormat(strings.NewReader(logs), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
 	return ret, err
 }
 
-// ParseGitLog parses git loh output to a list of commits.
+// walkGitLogFormat calls fn with each commit of r, see ParseGitLogFormat.
+func walkGitLogFormat(r io.Reader, fn driver.WalkFunc) error {
+	br := bufio.NewReader(r)
+	head, err := br.ReadString('\x1e')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if strings.TrimSpace(strings.TrimSuffix(head, "\x1e")) != "" {
+		return errUnexpectedLog
+	}
+	for err == nil {
+		var record string
+		if record, err = br.ReadString('\x1e'); err != nil && err != io.EOF {
+			return err
+		}
+		c, err2 := parseGitLogRecord(strings.TrimSuffix(record, "\x1e"))
+		if err2 != nil {
+			return err2
+		}
+		if err2 = fn(c); err2 != nil {
+			return err2
+		}
+	}
+	return nil
+}
+
+// parseGitLogRecord parses the fields of a commit formatted with logFormat.
+func parseGitLogRecord(record string) (commit.Commit, error) {
+	fields := strings.SplitN(record, "\x00", 11)
+	if len(fields) != 11 {
+		return commit.Commit{}, fmt.Errorf("%w: unexpected record", errUnexpectedLog)
+	}
+	c := commit.Commit{
+		Revision:       fields[0],
+		ShortRevision:  fields[1],
+		Author:         fields[3],
+		Email:          fields[4],
+		Date:           fields[5],
+		Committer:      fields[6],
+		CommitterEmail: fields[7],
+		Message:        strings.TrimSpace(fields[9]),
+	}
+	if parents := strings.Fields(fields[2]); len(parents) > 0 {
+		c.Parents = parents
+	}
+	if d, err := commit.ParseDate(fields[8]); err == nil {
+		c.CommitterTime = d
+	}
+	c.Files = parseNameStatus(fields[10])
+	c.Complete()
+	return c, nil
+}
+
+// parseNameStatus parses the NUL separated output of git log --name-status -z.
+func parseNameStatus(out string) []commit.File {
+	var files []commit.File
+	tokens := strings.Split(strings.TrimLeft(out, "\x00\n"), "\x00")
+	for i := 0; i < len(tokens); i++ {
+		status := strings.TrimSpace(tokens[i])
+		if status == "" {
+			continue
+		}
+		if (status[0] == 'R' || status[0] == 'C') && i+2 < len(tokens) {
+			f := commit.File{Action: commit.Renamed, From: tokens[i+1], Path: tokens[i+2]}
+			if status[0] == 'C' {
+				f = commit.File{Action: commit.Added, Path: tokens[i+2]}
+			}
+			files = append(files, f)
+			i += 2
+			continue
+		}
+		if i+1 >= len(tokens) {
+			break
+		}
+		action := commit.Modified
+		switch status[0] {
+		case 'A':
+			action = commit.Added
+		case 'D':
+			action = commit.Deleted
+		}
+		files = append(files, commit.File{Action: action, Path: tokens[i+1]})
+		i++
+	}
+	return files
+}
+
+// ParseGitLog parses git log default output to a list of commits,
+// it is used when the output of ParseGitLogFormat can not be parsed.
 func ParseGitLog(logs string) []commit.Commit {
 	ret := make([]commit.Commit, 0)
+	walkGitLog(strings.NewReader(logs), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret
+}
 
+// walkGitLog calls fn with each commit of r, see ParseGitLog.
+func walkGitLog(r io.Reader, fn driver.WalkFunc) error {
 	commitRe := regexp.MustCompile(`^commit\s+(.+)$`)
 	authorRe := regexp.MustCompile(`^Author:\s+([^<]+)\s+<([^>]+)>$`)
 	dateRe := regexp.MustCompile(`^Date:\s*(.+)$`)
 	messageRe := regexp.MustCompile(`^\s+(.+)$`)
 	var c *commit.Commit
-	for _, line := range strings.Split(logs, "\n") {
+	err := driver.Lines(r, func(line string) error {
 		if commitRe.MatchString(line) {
 			if c != nil {
-				ret = append(ret, *c)
+				if err := fn(*c); err != nil {
+					return err
+				}
 			}
 			c = &commit.Commit{}
 			res := commitRe.FindStringSubmatch(line)
@@ -204,43 +570,48 @@ func ParseGitLog(logs string) []commit.Commit {
 				c.Message = c.Message + "\n" + strings.TrimSpace(res[1])
 			}
 		}
+		return nil
+	})
+	if err == nil && c != nil && c.Revision != "" {
+		err = fn(*c)
 	}
-	if c != nil && c.Revision != "" {
-		ret = append(ret, *c)
-	}
-	return ret
+	return err
 }
 
 // GetRevisionTag get the revision of a tag
 func GetRevisionTag(path string, tag string) (string, error) {
-	ret := ""
+	return Driver{}.GetRevisionTagContext(context.Background(), path, tag)
+}
 
-	args := []string{"log", "-n", "1", tag}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return ret, err
-	}
+// GetRevisionTagContext is like GetRevisionTag, bounded by ctx.
+func GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(ctx, path, tag)
+}
+
+// GetRevisionTagContext get the revision of the commit pointed by a tag
+func (d Driver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	args := []string{"rev-parse", "--verify", tag + "^{commit}"}
+	out, err := d.run(ctx, path, args)
 
 	return strings.TrimSpace(string(out)), err
 }
 
 // GetFirstRevision returns the first revision of the repostiory
 func GetFirstRevision(path string) (string, error) {
-	ret := ""
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
 
-	args := []string{"rev-list", "--max-parents=0", "HEAD"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return ret, err
-	}
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+// GetFirstRevisionContext returns the first revision of the repostiory
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+
+	args := []string{"rev-list", "--max-parents=0", "HEAD"}
+	out, err := d.run(ctx, path, args)
 
 	// when a merge has occured, it will return multiple hash,
 	// take the last one only
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+)
+
+func TestListCommitsBetweenMessage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q")
+	message := "tomate\n\ncommit abc\nAuthor: x <y>\nDate: today\n\n    indented"
+	gitRun(t, dir, "commit", "-q", "--allow-empty", "-m", message)
+
+	commits, err := Driver{}.ListCommitsBetweenContext(context.Background(), dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d: %+v", len(commits), commits)
+	}
+	if commits[0].Message != message {
+		t.Errorf("Expected message=%q, got %q", message, commits[0].Message)
+	}
+	if commits[0].Author != "John Doe" || commits[0].Email != "john@doe.com" {
+		t.Errorf("Expected author John Doe <john@doe.com>, got %s <%s>", commits[0].Author, commits[0].Email)
+	}
+	if commits[0].GetDate() == nil {
+		t.Errorf("Expected a parsable date, got %q", commits[0].Date)
+	}
+}
+
+func TestWalkCommitsBetweenStop(t *testing.T) {
+	dir := nativeRepo(t)
+	for _, d := range []interface {
+		driver.Vcs
+		driver.Walker
+	}{Driver{}, NativeDriver{}} {
+		want, err := d.ListCommitsBetweenContext(context.Background(), dir, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []commit.Commit{}
+		err = d.WalkCommitsBetweenContext(context.Background(), dir, "", "", func(c commit.Commit) error {
+			got = append(got, c)
+			if len(got) == 2 {
+				return driver.ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%T: expected no error, got %v", d, err)
+		}
+		if reflect.DeepEqual(got, want[:2]) == false {
+			t.Errorf("%T: expected %+v, got %+v", d, want[:2], got)
+		}
+
+		failure := errors.New("failure")
+		err = d.WalkCommitsBetweenContext(context.Background(), dir, "", "", func(c commit.Commit) error {
+			return failure
+		})
+		if err != failure {
+			t.Errorf("%T: expected %v, got %v", d, failure, err)
+		}
+	}
+}
+
+func TestListTagsDetailed(t *testing.T) {
+	dir := nativeRepo(t)
+	tags, err := Driver{}.ListTagsDetailedContext(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 3 {
+		t.Fatalf("Expected 3 tags, got %+v", tags)
+	}
+	annotated, lightweight := tags[1], tags[2]
+	if annotated.Name != "v1.0.0" || annotated.Annotated == false || annotated.Message != "release v1.0.0" ||
+		annotated.Tagger != "John Doe" || annotated.TaggerEmail != "john@doe.com" || annotated.TaggerTime.IsZero() {
+		t.Errorf("Unexpected annotated tag %+v", annotated)
+	}
+	if lightweight.Name != "v1.0.2" || lightweight.Annotated || lightweight.Tagger != "" || lightweight.Message != "" {
+		t.Errorf("Unexpected lightweight tag %+v", lightweight)
+	}
+	if d := annotated.Date.Format(time.RFC3339); d != "2017-01-02T10:00:00+02:00" {
+		t.Errorf("Unexpected tag date %s", d)
+	}
+	if len(annotated.Revision) != 40 || annotated.Revision == lightweight.Revision {
+		t.Errorf("Unexpected tag revisions %q %q", annotated.Revision, lightweight.Revision)
+	}
+}
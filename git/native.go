@@ -0,0 +1,183 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+)
+
+// NativeDriver implements driver.Vcs, driver.Walker and driver.TagDetailer for git without the git binary
+// for the read operations, it reads the refs and the objects of the .git directory.
+// The other operations are delegated to Driver.
+type NativeDriver struct {
+	Driver
+}
+
+// Configure returns a native git driver using given config.
+func (d NativeDriver) Configure(c driver.Config) driver.Vcs {
+	return NativeDriver{Driver: Driver{Config: c}}
+}
+
+// open returns the repository of path and ctx bounded by the configured timeout.
+func (d NativeDriver) open(ctx context.Context, path string) (*repository, context.Context, context.CancelFunc, error) {
+	ctx, cancel := d.Context(ctx)
+	r, err := openRepository(path)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	d.logger().Printf("native git %s (cwd=%s)", r.gitDir, path)
+	return r, ctx, cancel, nil
+}
+
+// abort translates the ctx errors of the native operation op.
+func abort(ctx context.Context, op string, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return &driver.TimeoutError{Args: []string{"git", op}, Err: ctx.Err()}
+	}
+	return err
+}
+
+// IsItContext Test if given path is within a git directory.
+func (d NativeDriver) IsItContext(ctx context.Context, path string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, driver.ProbeError(err)
+	}
+	r, err := openRepository(path)
+	if err != nil {
+		return false, nil
+	}
+	r.close()
+	return true, nil
+}
+
+// ListContext lists tags on given path from the refs/tags references.
+func (d NativeDriver) ListContext(ctx context.Context, path string) ([]string, error) {
+	r, _, cancel, err := d.open(ctx, path)
+	if err != nil {
+		return make([]string, 0), err
+	}
+	defer cancel()
+	defer r.close()
+	return r.tags(), nil
+}
+
+// ListTagsDetailedContext lists the tags on given path from the refs/tags references and the tag objects.
+func (d NativeDriver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	r, _, cancel, err := d.open(ctx, path)
+	if err != nil {
+		return make([]driver.Tag, 0), err
+	}
+	defer cancel()
+	defer r.close()
+	return r.tagsDetailed()
+}
+
+// GetRevisionTagContext get the revision of the commit pointed by a tag
+func (d NativeDriver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	r, _, cancel, err := d.open(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+	defer r.close()
+	return r.resolve(tag)
+}
+
+// ListCommitsBetweenContext List commits between two points,
+// they are ordered as git log does. The renamed files are listed as deleted and added.
+func (d NativeDriver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points,
+// as they are read from the objects.
+func (d NativeDriver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, as they are read from the objects.
+func (d NativeDriver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
+	r, ctx, cancel, err := d.open(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer r.close()
+
+	toRev, err := r.resolve(to)
+	if err != nil {
+		return err
+	}
+	exclude := map[string]bool{}
+	if since != "" {
+		sinceRev, err := r.resolve(since)
+		if err != nil {
+			return err
+		}
+		if exclude, err = r.reachable(ctx, sinceRev); err != nil {
+			return abort(ctx, "log", err)
+		}
+	}
+
+	paths := make([]string, 0, len(opts.Paths))
+	for _, p := range opts.Paths {
+		paths = append(paths, filepath.ToSlash(filepath.Join(filepath.FromSlash(r.prefix), p)))
+	}
+	fn, err = opts.Filter(fn)
+	if err != nil {
+		return err
+	}
+
+	err = r.walk(ctx, toRev, exclude, func(c *rawCommit) error {
+		ci := r.toCommit(c)
+		if d.Files || len(paths) > 0 {
+			var err error
+			if ci.Files, err = r.files(c); err != nil {
+				return err
+			}
+		}
+		if len(paths) > 0 && driver.MatchPaths(paths, ci.Files) == false {
+			return nil
+		}
+		if d.Files == false {
+			ci.Files = nil
+		}
+		return fn(ci)
+	})
+	return driver.Stopped(abort(ctx, "log", err))
+}
+
+// GetFirstRevisionContext returns the first revision of the repostiory
+func (d NativeDriver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	r, ctx, cancel, err := d.open(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+	defer r.close()
+
+	head, err := r.resolve("HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	// when a merge has occured, there are multiple roots,
+	// take the last one only
+	first := ""
+	err = r.walk(ctx, head, nil, func(c *rawCommit) error {
+		if len(c.parents) == 0 {
+			first = c.hash
+		}
+		return nil
+	})
+	return first, abort(ctx, "rev-list", err)
+}
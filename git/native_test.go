@@ -0,0 +1,217 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=John Doe", "GIT_AUTHOR_EMAIL=john@doe.com",
+		"GIT_COMMITTER_NAME=John Doe", "GIT_COMMITTER_EMAIL=john@doe.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// nativeRepo creates a repository with a merge, lightweight and annotated tags.
+func nativeRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "master")
+	content := ""
+	for i, tag := range []string{"notsemvertag", "v1.0.0", "", "v1.0.2", ""} {
+		content += strings.Repeat("tomate line\n", 50) + tag + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "tomate"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "sub", "dir"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "sub", "dir", tag+".txt"), []byte(tag), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitRun(t, dir, "add", "-A")
+		gitRun(t, dir, "commit", "-q", "-m", "tomate "+tag, "-m", "body", "--date", "2017-01-0"+string(rune('1'+i))+"T10:00:00+0200")
+		if tag == "v1.0.0" {
+			gitRun(t, dir, "tag", "-a", tag, "-m", "release "+tag)
+		} else if tag != "" {
+			gitRun(t, dir, "tag", tag)
+		}
+	}
+	gitRun(t, dir, "checkout", "-q", "-b", "feature", "v1.0.0")
+	if err := os.WriteFile(filepath.Join(dir, "feature"), []byte("feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "feature")
+	gitRun(t, dir, "checkout", "-q", "master")
+	gitRun(t, dir, "merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+	return dir
+}
+
+func compareDrivers(t *testing.T, dir string) {
+	ctx := context.Background()
+	cli, native := Driver{}, NativeDriver{}
+
+	want, err := cli.ListContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := native.ListContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(got, want) == false {
+		t.Errorf("List: expected %q, got %q", want, got)
+	}
+
+	wantTags, err := cli.ListTagsDetailedContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTags, err := native.ListTagsDetailedContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(gotTags, wantTags) == false {
+		t.Errorf("ListTagsDetailed:\nexpected %+v\ngot      %+v", wantTags, gotTags)
+	}
+	for _, tag := range wantTags {
+		rev, err := native.GetRevisionTagContext(ctx, dir, tag.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rev != tag.Revision {
+			t.Errorf("GetRevisionTag(%q): expected %q, got %q", tag.Name, tag.Revision, rev)
+		}
+		if rev, err = cli.GetRevisionTagContext(ctx, dir, tag.Name); err != nil || rev != tag.Revision {
+			t.Errorf("GetRevisionTag(%q): expected %q, got %q %v", tag.Name, tag.Revision, rev, err)
+		}
+	}
+
+	wantFirst, err := cli.GetFirstRevisionContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotFirst, err := native.GetFirstRevisionContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotFirst != wantFirst {
+		t.Errorf("GetFirstRevision: expected %q, got %q", wantFirst, gotFirst)
+	}
+
+	ranges := [][2]string{{"", ""}, {"", "HEAD"}, {"v1.0.0", ""}, {"notsemvertag", "v1.0.2"}, {"v1.0.2", "feature"}, {"", "HEAD~2"}}
+	for _, r := range ranges {
+		want, err := cli.ListCommitsBetweenContext(ctx, dir, r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := native.ListCommitsBetweenContext(ctx, dir, r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.DeepEqual(got, want) == false {
+			t.Errorf("ListCommitsBetween(%q, %q):\nexpected %+v\ngot      %+v", r[0], r[1], want, got)
+		}
+	}
+}
+
+func compareFiles(t *testing.T, dir string) {
+	ctx := context.Background()
+	config := driver.Config{Files: true}
+	cli, native := Driver{}.Configure(config), NativeDriver{}.Configure(config)
+
+	want, err := cli.ListCommitsBetweenContext(ctx, dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := native.ListCommitsBetweenContext(ctx, dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(got, want) == false {
+		t.Errorf("ListCommitsBetween with files:\nexpected %+v\ngot      %+v", want, got)
+	}
+}
+
+// compareFilters compares the commits selected by the filters applied by git and in go.
+func compareFilters(t *testing.T, dir string) {
+	ctx := context.Background()
+	filters := []driver.ListOptions{
+		{CommitFilter: driver.CommitFilter{Paths: []string{"sub"}}},
+		{CommitFilter: driver.CommitFilter{Paths: []string{"sub/dir/v1.0.0.txt", "feature"}}},
+		{CommitFilter: driver.CommitFilter{Author: "JOHN@doe"}},
+		{Since: "v1.0.0", CommitFilter: driver.CommitFilter{Skip: 1, MaxCount: 2}},
+		{CommitFilter: driver.CommitFilter{Message: "^tomate v", MaxCount: 1}},
+	}
+	for _, opts := range filters {
+		want, got := []string{}, []string{}
+		err := Driver{}.WalkCommitsContext(ctx, dir, opts, func(c commit.Commit) error {
+			want = append(want, c.Revision)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = NativeDriver{}.WalkCommitsContext(ctx, dir, opts, func(c commit.Commit) error {
+			got = append(got, c.Revision)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(opts.Paths) > 0 {
+			// git simplifies the history of the paths, the commits at the same date may be reordered
+			sort.Strings(want)
+			sort.Strings(got)
+		}
+		if len(want) == 0 || reflect.DeepEqual(got, want) == false {
+			t.Errorf("WalkCommits(%+v): expected %q, got %q", opts, want, got)
+		}
+	}
+}
+
+func TestNativeLoose(t *testing.T) {
+	dir := nativeRepo(t)
+	compareDrivers(t, dir)
+	compareFiles(t, dir)
+	compareFilters(t, dir)
+}
+
+func TestNativePacked(t *testing.T) {
+	dir := nativeRepo(t)
+	gitRun(t, dir, "gc", "-q", "--aggressive")
+	gitRun(t, dir, "pack-refs", "--all")
+	compareDrivers(t, dir)
+	compareFiles(t, dir)
+}
+
+func TestNativeWorktree(t *testing.T) {
+	dir := nativeRepo(t)
+	wt := filepath.Join(t.TempDir(), "wt")
+	gitRun(t, dir, "worktree", "add", "-q", wt, "v1.0.2")
+	compareDrivers(t, wt)
+}
+
+func TestNativeNotARepository(t *testing.T) {
+	_, err := NativeDriver{}.ListContext(context.Background(), t.TempDir())
+	if errors.Is(err, driver.ErrNotARepository) == false {
+		t.Errorf("Expected ErrNotARepository, got %v", err)
+	}
+}
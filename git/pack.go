@@ -0,0 +1,296 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+var errBadPack = errors.New("malformed pack")
+
+// objectTypes are the names of the pack object types.
+var objectTypes = map[byte]string{1: "commit", 2: "tree", 3: "blob", 4: "tag"}
+
+const (
+	ofsDelta = 6
+	refDelta = 7
+)
+
+// pack is a pack file with its index.
+type pack struct {
+	path    string
+	file    *os.File
+	fanout  [256]uint32
+	names   []byte
+	offsets []int64
+}
+
+// openPack reads the index idx, the pack file is opened on first read.
+func openPack(idx string) (*pack, error) {
+	b, err := os.ReadFile(idx)
+	if err != nil {
+		return nil, err
+	}
+	p := &pack{path: strings.TrimSuffix(idx, ".idx") + ".pack"}
+
+	if len(b) >= 8 && bytes.Equal(b[:4], []byte{0xff, 't', 'O', 'c'}) {
+		if binary.BigEndian.Uint32(b[4:8]) != 2 {
+			return nil, fmt.Errorf("%w: unsupported index version in %s", errBadPack, idx)
+		}
+		return p, p.readIndexV2(b[8:])
+	}
+	return p, p.readIndexV1(b)
+}
+
+func (p *pack) readFanout(b []byte) (int, error) {
+	if len(b) < 1024 {
+		return 0, fmt.Errorf("%w: truncated index of %s", errBadPack, p.path)
+	}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	return int(p.fanout[255]), nil
+}
+
+func (p *pack) readIndexV1(b []byte) error {
+	n, err := p.readFanout(b)
+	if err != nil {
+		return err
+	}
+	b = b[1024:]
+	if len(b) < n*24 {
+		return fmt.Errorf("%w: truncated index of %s", errBadPack, p.path)
+	}
+	p.names = make([]byte, 0, n*20)
+	p.offsets = make([]int64, n)
+	for i := 0; i < n; i++ {
+		e := b[i*24:]
+		p.offsets[i] = int64(binary.BigEndian.Uint32(e))
+		p.names = append(p.names, e[4:24]...)
+	}
+	return nil
+}
+
+func (p *pack) readIndexV2(b []byte) error {
+	n, err := p.readFanout(b)
+	if err != nil {
+		return err
+	}
+	b = b[1024:]
+	if len(b) < n*28 {
+		return fmt.Errorf("%w: truncated index of %s", errBadPack, p.path)
+	}
+	p.names = b[:n*20]
+	small := b[n*24 : n*28]
+	large := b[n*28:]
+	p.offsets = make([]int64, n)
+	for i := 0; i < n; i++ {
+		o := binary.BigEndian.Uint32(small[i*4:])
+		if o&0x80000000 == 0 {
+			p.offsets[i] = int64(o)
+			continue
+		}
+		k := int(o & 0x7fffffff)
+		if len(large) < (k+1)*8 {
+			return fmt.Errorf("%w: truncated index of %s", errBadPack, p.path)
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(large[k*8:]))
+	}
+	return nil
+}
+
+// bucket returns the range of the entries starting with given byte.
+func (p *pack) bucket(first byte) (int, int) {
+	lo := 0
+	if first > 0 {
+		lo = int(p.fanout[first-1])
+	}
+	return lo, int(p.fanout[first])
+}
+
+func (p *pack) name(i int) []byte {
+	return p.names[i*20 : i*20+20]
+}
+
+// find returns the offset of the object with given binary hash.
+func (p *pack) find(hash []byte) (int64, bool) {
+	lo, hi := p.bucket(hash[0])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.name(lo+i), hash) >= 0
+	})
+	if i < hi && bytes.Equal(p.name(i), hash) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// findPrefix returns the hashes starting with given hexadecimal prefix.
+func (p *pack) findPrefix(prefix string) []string {
+	ret := make([]string, 0)
+	first, err := hex.DecodeString(prefix[:2])
+	if err != nil {
+		return ret
+	}
+	lo, hi := p.bucket(first[0])
+	for i := lo; i < hi; i++ {
+		h := hex.EncodeToString(p.name(i))
+		if strings.HasPrefix(h, prefix) {
+			ret = append(ret, h)
+		}
+	}
+	return ret
+}
+
+func (p *pack) close() {
+	if p.file != nil {
+		p.file.Close()
+		p.file = nil
+	}
+}
+
+// object reads the object at offset, ref deltas are resolved with r.
+func (p *pack) object(r *repository, offset int64) (string, []byte, error) {
+	if p.file == nil {
+		f, err := os.Open(p.path)
+		if err != nil {
+			return "", nil, err
+		}
+		p.file = f
+	}
+	br := bufio.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+
+	c, err := br.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	typ := (c >> 4) & 7
+	size := uint64(c & 0x0f)
+	for shift := uint(4); c&0x80 != 0; shift += 7 {
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+
+	if name, ok := objectTypes[typ]; ok {
+		data, err := inflate(br, size)
+		return name, data, err
+	}
+
+	var baseType string
+	var base []byte
+	switch typ {
+	case ofsDelta:
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = ((rel + 1) << 7) | int64(c&0x7f)
+		}
+		baseType, base, err = p.object(r, offset-rel)
+	case refDelta:
+		h := make([]byte, 20)
+		if _, err = io.ReadFull(br, h); err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = r.object(hex.EncodeToString(h))
+	default:
+		err = fmt.Errorf("%w: unknown object type %d in %s", errBadPack, typ, p.path)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	delta, err := inflate(br, size)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := applyDelta(base, delta)
+	return baseType, data, err
+}
+
+func inflate(r io.Reader, size uint64) ([]byte, error) {
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	data := make([]byte, size)
+	_, err = io.ReadFull(z, data)
+	return data, err
+}
+
+// deltaSize reads a size of the delta header.
+func deltaSize(delta []byte) (uint64, []byte) {
+	var size uint64
+	for i, shift := 0, uint(0); i < len(delta); i, shift = i+1, shift+7 {
+		size |= uint64(delta[i]&0x7f) << shift
+		if delta[i]&0x80 == 0 {
+			return size, delta[i+1:]
+		}
+	}
+	return size, nil
+}
+
+// applyDelta rebuilds an object from its base and a delta.
+func applyDelta(base []byte, delta []byte) ([]byte, error) {
+	srcSize, delta := deltaSize(delta)
+	if srcSize != uint64(len(base)) {
+		return nil, fmt.Errorf("%w: delta base size mismatch", errBadPack)
+	}
+	dstSize, delta := deltaSize(delta)
+	out := make([]byte, 0, dstSize)
+
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 == 0 {
+			n := int(op)
+			if n == 0 || n > len(delta) {
+				return nil, fmt.Errorf("%w: bad delta instruction", errBadPack)
+			}
+			out = append(out, delta[:n]...)
+			delta = delta[n:]
+			continue
+		}
+		var offset, size uint64
+		for i := uint(0); i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, fmt.Errorf("%w: truncated delta", errBadPack)
+			}
+			if i < 4 {
+				offset |= uint64(delta[0]) << (8 * i)
+			} else {
+				size |= uint64(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > uint64(len(base)) {
+			return nil, fmt.Errorf("%w: delta copy out of bounds", errBadPack)
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+
+	if uint64(len(out)) != dstSize {
+		return nil, fmt.Errorf("%w: delta result size mismatch", errBadPack)
+	}
+	return out, nil
+}
@@ -0,0 +1,699 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"container/heap"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+)
+
+// repository reads the references and the objects of a git directory,
+// it does not need the git binary.
+type repository struct {
+	// gitDir holds HEAD, it is the worktree directory in linked worktrees.
+	gitDir string
+	// commonDir holds the objects and the shared references.
+	commonDir string
+	// prefix is the slash separated path of the opened directory within the worktree.
+	prefix  string
+	packs   []*pack
+	shallow map[string]bool
+}
+
+// openRepository finds the git directory of path, walking up to its parents.
+func openRepository(path string) (*repository, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	abs := dir
+	gitDir, prefix := "", ""
+	for gitDir == "" {
+		if g := dotGit(dir); g != "" {
+			gitDir = g
+			if rel, err := filepath.Rel(dir, abs); err == nil && rel != "." {
+				prefix = filepath.ToSlash(rel)
+			}
+		} else if isGitDir(dir) {
+			gitDir = dir
+		} else {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return nil, fmt.Errorf("No git repository found at '%s': %w", path, driver.ErrNotARepository)
+			}
+			dir = parent
+		}
+	}
+
+	r := &repository{gitDir: gitDir, commonDir: gitDir, prefix: prefix, shallow: map[string]bool{}}
+	if b, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		r.commonDir = relTo(gitDir, strings.TrimSpace(string(b)))
+	}
+
+	idx, _ := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+	for _, f := range idx {
+		p, err := openPack(f)
+		if err != nil {
+			return nil, err
+		}
+		r.packs = append(r.packs, p)
+	}
+
+	if b, err := os.ReadFile(filepath.Join(r.commonDir, "shallow")); err == nil {
+		for _, h := range strings.Fields(string(b)) {
+			r.shallow[h] = true
+		}
+	}
+	return r, nil
+}
+
+// dotGit returns the git directory designated by dir/.git, if any.
+func dotGit(dir string) string {
+	p := filepath.Join(dir, ".git")
+	s, err := os.Stat(p)
+	if err != nil {
+		return ""
+	}
+	if s.IsDir() {
+		return p
+	}
+	b, err := os.ReadFile(p)
+	if err != nil || strings.HasPrefix(string(b), "gitdir:") == false {
+		return ""
+	}
+	return relTo(dir, strings.TrimSpace(strings.TrimPrefix(string(b), "gitdir:")))
+}
+
+// isGitDir tells if dir is itself a git directory, as in bare repositories.
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func relTo(dir string, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func (r *repository) close() {
+	for _, p := range r.packs {
+		p.close()
+	}
+}
+
+// packedRefs returns the references of the packed-refs file.
+func (r *repository) packedRefs() map[string]string {
+	ret := map[string]string{}
+	b, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return ret
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		k := strings.SplitN(line, " ", 2)
+		if len(k) == 2 {
+			ret[strings.TrimSpace(k[1])] = k[0]
+		}
+	}
+	return ret
+}
+
+// refs returns the references under prefix, such as refs/tags/, with their hash.
+func (r *repository) refs(prefix string) map[string]string {
+	ret := map[string]string{}
+	for name, hash := range r.packedRefs() {
+		if strings.HasPrefix(name, prefix) {
+			ret[name] = hash
+		}
+	}
+	root := filepath.Join(r.commonDir, filepath.FromSlash(prefix))
+	filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(r.commonDir, p)
+		if err != nil {
+			return nil
+		}
+		if hash, err := r.ref(filepath.ToSlash(rel)); err == nil {
+			ret[filepath.ToSlash(rel)] = hash
+		}
+		return nil
+	})
+	return ret
+}
+
+// ref resolves the reference name to a hash, following symbolic references.
+func (r *repository) ref(name string) (string, error) {
+	for i := 0; i < 10; i++ {
+		dir := r.commonDir
+		if strings.Contains(name, "/") == false {
+			dir = r.gitDir
+		}
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			if hash, ok := r.packedRefs()[name]; ok {
+				return hash, nil
+			}
+			return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, name)
+		}
+		s := strings.TrimSpace(string(b))
+		if strings.HasPrefix(s, "ref:") == false {
+			return s, nil
+		}
+		name = strings.TrimSpace(strings.TrimPrefix(s, "ref:"))
+	}
+	return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, name)
+}
+
+var hexRe = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+var pseudoRefRe = regexp.MustCompile(`^[A-Z_]+$`)
+var revSuffixRe = regexp.MustCompile(`^([~^])([0-9]*)`)
+
+// resolve returns the commit hash designated by rev,
+// a reference, a hash, or an abbreviated hash, followed by ~n and ^n suffixes.
+func (r *repository) resolve(rev string) (string, error) {
+	name := rev
+	suffix := ""
+	if i := strings.IndexAny(rev, "~^"); i > -1 {
+		name, suffix = rev[:i], rev[i:]
+	}
+	if name == "" {
+		name = "HEAD"
+	}
+
+	hash, err := r.resolveName(name)
+	if err != nil {
+		return "", err
+	}
+	if hash, err = r.peel(hash); err != nil {
+		return "", err
+	}
+
+	for len(suffix) > 0 {
+		if strings.HasPrefix(suffix, "^{}") || strings.HasPrefix(suffix, "^{commit}") {
+			suffix = suffix[strings.Index(suffix, "}")+1:]
+			continue
+		}
+		res := revSuffixRe.FindStringSubmatch(suffix)
+		if res == nil {
+			return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, rev)
+		}
+		suffix = suffix[len(res[0]):]
+		n := 1
+		if res[2] != "" {
+			n, _ = strconv.Atoi(res[2])
+		}
+		if res[1] == "^" {
+			if n == 0 {
+				continue
+			}
+			c, err := r.commit(hash)
+			if err != nil {
+				return "", err
+			}
+			if n > len(c.parents) {
+				return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, rev)
+			}
+			hash = c.parents[n-1]
+			continue
+		}
+		for ; n > 0; n-- {
+			c, err := r.commit(hash)
+			if err != nil {
+				return "", err
+			}
+			if len(c.parents) == 0 {
+				return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, rev)
+			}
+			hash = c.parents[0]
+		}
+	}
+	return hash, nil
+}
+
+// resolveName looks up name in the same order as git rev-parse.
+func (r *repository) resolveName(name string) (string, error) {
+	candidates := []string{
+		"refs/" + name,
+		"refs/tags/" + name,
+		"refs/heads/" + name,
+		"refs/remotes/" + name,
+		"refs/remotes/" + name + "/HEAD",
+	}
+	// as git, only the upper case names, such as HEAD, are looked up out of refs/
+	if strings.HasPrefix(name, "refs/") || pseudoRefRe.MatchString(name) {
+		candidates = append([]string{name}, candidates...)
+	}
+	for _, c := range candidates {
+		if hash, err := r.ref(c); err == nil {
+			return hash, nil
+		}
+	}
+	if hexRe.MatchString(name) {
+		return r.expand(name)
+	}
+	return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, name)
+}
+
+// expand returns the full hash of an abbreviated hash.
+func (r *repository) expand(prefix string) (string, error) {
+	if len(prefix) == 40 {
+		return prefix, nil
+	}
+	found := map[string]bool{}
+	entries, _ := os.ReadDir(filepath.Join(r.commonDir, "objects", prefix[:2]))
+	for _, e := range entries {
+		if strings.HasPrefix(prefix[:2]+e.Name(), prefix) {
+			found[prefix[:2]+e.Name()] = true
+		}
+	}
+	for _, p := range r.packs {
+		for _, h := range p.findPrefix(prefix) {
+			found[h] = true
+		}
+	}
+	if len(found) != 1 {
+		return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, prefix)
+	}
+	for h := range found {
+		return h, nil
+	}
+	return "", nil
+}
+
+// peel follows annotated tags down to the commit they point to.
+func (r *repository) peel(hash string) (string, error) {
+	for i := 0; i < 10; i++ {
+		typ, data, err := r.object(hash)
+		if err != nil {
+			return "", err
+		}
+		if typ != "tag" {
+			return hash, nil
+		}
+		target := header(data, "object")
+		if target == "" {
+			return "", fmt.Errorf("%w: malformed tag %s", driver.ErrUnknownRevision, hash)
+		}
+		hash = target
+	}
+	return "", fmt.Errorf("%w: %s", driver.ErrUnknownRevision, hash)
+}
+
+// object reads the object of given hash, from the loose objects or the packs.
+func (r *repository) object(hash string) (string, []byte, error) {
+	bin, err := hex.DecodeString(hash)
+	if err != nil || len(bin) != 20 {
+		return "", nil, fmt.Errorf("%w: %s", driver.ErrUnknownRevision, hash)
+	}
+
+	f, err := os.Open(filepath.Join(r.commonDir, "objects", hash[:2], hash[2:]))
+	if err == nil {
+		defer f.Close()
+		return looseObject(f)
+	}
+
+	for _, p := range r.packs {
+		if offset, ok := p.find(bin); ok {
+			return p.object(r, offset)
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %s", driver.ErrUnknownRevision, hash)
+}
+
+func looseObject(f io.Reader) (string, []byte, error) {
+	z, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer z.Close()
+	b, err := io.ReadAll(z)
+	if err != nil {
+		return "", nil, err
+	}
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", nil, fmt.Errorf("malformed object header")
+	}
+	k := strings.SplitN(string(b[:i]), " ", 2)
+	return k[0], b[i+1:], nil
+}
+
+// header returns the value of the first header named key of a commit or a tag.
+func header(data []byte, key string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, key+" ") {
+			return strings.TrimPrefix(line, key+" ")
+		}
+	}
+	return ""
+}
+
+// rawCommit is a commit object.
+type rawCommit struct {
+	hash           string
+	tree           string
+	parents        []string
+	author         string
+	email          string
+	authorDate     time.Time
+	committer      string
+	committerEmail string
+	commitDate     time.Time
+	message        string
+}
+
+var signatureRe = regexp.MustCompile(`^(.*?)\s*<([^>]*)>\s+([0-9]+)\s+([+-][0-9]{4})$`)
+
+// parseSignature parses the value of an author or committer header.
+func parseSignature(s string) (string, string, time.Time) {
+	res := signatureRe.FindStringSubmatch(s)
+	if res == nil {
+		return s, "", time.Time{}
+	}
+	ts, _ := strconv.ParseInt(res[3], 10, 64)
+	tz, _ := strconv.Atoi(res[4])
+	offset := (tz/100*60 + tz%100) * 60
+	date := time.Unix(ts, 0).In(time.FixedZone("", offset))
+	return res[1], res[2], date
+}
+
+// commit reads the commit of given hash.
+func (r *repository) commit(hash string) (*rawCommit, error) {
+	typ, data, err := r.object(hash)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "commit" {
+		return nil, fmt.Errorf("%w: %s is a %s", driver.ErrUnknownRevision, hash, typ)
+	}
+
+	c := &rawCommit{hash: hash}
+	head, message := string(data), ""
+	if i := strings.Index(head, "\n\n"); i > -1 {
+		head, message = head[:i], head[i+2:]
+	}
+	for _, line := range strings.Split(head, "\n") {
+		k := strings.SplitN(line, " ", 2)
+		if len(k) != 2 {
+			continue
+		}
+		switch k[0] {
+		case "tree":
+			c.tree = k[1]
+		case "parent":
+			if r.shallow[hash] == false {
+				c.parents = append(c.parents, k[1])
+			}
+		case "author":
+			c.author, c.email, c.authorDate = parseSignature(k[1])
+		case "committer":
+			c.committer, c.committerEmail, c.commitDate = parseSignature(k[1])
+		}
+	}
+
+	c.message = strings.TrimSpace(message)
+	return c, nil
+}
+
+// toCommit formats c as the git log output parsed by ParseGitLogFormat.
+func (r *repository) toCommit(c *rawCommit) commit.Commit {
+	ret := commit.Commit{
+		Revision:       c.hash,
+		ShortRevision:  r.abbrev(c.hash),
+		Parents:        c.parents,
+		Author:         c.author,
+		Email:          c.email,
+		Date:           c.authorDate.Format(time.RFC3339),
+		Committer:      c.committer,
+		CommitterEmail: c.committerEmail,
+		Message:        c.message,
+	}
+	ret.CommitterTime = normalizeDate(c.commitDate)
+	ret.Complete()
+	return ret
+}
+
+// abbrev returns the shortest unique prefix of hash, of at least 7 characters, as %h.
+func (r *repository) abbrev(hash string) string {
+	for n := 7; n < len(hash); n++ {
+		if _, err := r.expand(hash[:n]); err == nil {
+			return hash[:n]
+		}
+	}
+	return hash
+}
+
+// treeEntry is an entry of a tree object.
+type treeEntry struct {
+	mode string
+	hash string
+}
+
+func (e treeEntry) isTree() bool {
+	return e.mode == "40000"
+}
+
+// tree reads the entries of the tree of given hash.
+func (r *repository) tree(hash string) (map[string]treeEntry, error) {
+	ret := map[string]treeEntry{}
+	if hash == "" {
+		return ret, nil
+	}
+	typ, data, err := r.object(hash)
+	if err != nil {
+		return ret, err
+	}
+	if typ != "tree" {
+		return ret, fmt.Errorf("%w: %s is a %s", driver.ErrUnknownRevision, hash, typ)
+	}
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+21 {
+			return ret, fmt.Errorf("malformed tree %s", hash)
+		}
+		ret[string(data[sp+1:nul])] = treeEntry{
+			mode: string(data[:sp]),
+			hash: hex.EncodeToString(data[nul+1 : nul+21]),
+		}
+		data = data[nul+21:]
+	}
+	return ret, nil
+}
+
+// diffTrees appends the files changed from the tree from to the tree to,
+// an empty hash is an empty tree. Renames are not detected.
+func (r *repository) diffTrees(from string, to string, prefix string, files []commit.File) ([]commit.File, error) {
+	a, err := r.tree(from)
+	if err != nil {
+		return files, err
+	}
+	b, err := r.tree(to)
+	if err != nil {
+		return files, err
+	}
+
+	names := map[string]bool{}
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	for name := range names {
+		ea, inA := a[name]
+		eb, inB := b[name]
+		if inA && inB && ea == eb {
+			continue
+		}
+		p := prefix + name
+		if ea.isTree() || eb.isTree() {
+			subA, subB := "", ""
+			if ea.isTree() {
+				subA = ea.hash
+			} else if inA {
+				files = append(files, commit.File{Action: commit.Deleted, Path: p})
+			}
+			if eb.isTree() {
+				subB = eb.hash
+			} else if inB {
+				files = append(files, commit.File{Action: commit.Added, Path: p})
+			}
+			if files, err = r.diffTrees(subA, subB, p+"/", files); err != nil {
+				return files, err
+			}
+			continue
+		}
+		action := commit.Modified
+		if inA == false {
+			action = commit.Added
+		} else if inB == false {
+			action = commit.Deleted
+		}
+		files = append(files, commit.File{Action: action, Path: p})
+	}
+	return files, nil
+}
+
+// files returns the files changed by c, as git log --name-status does,
+// merges do not list files.
+func (r *repository) files(c *rawCommit) ([]commit.File, error) {
+	if len(c.parents) > 1 {
+		return nil, nil
+	}
+	from := ""
+	if len(c.parents) == 1 {
+		p, err := r.commit(c.parents[0])
+		if err != nil {
+			return nil, err
+		}
+		from = p.tree
+	}
+	files, err := r.diffTrees(from, c.tree, "", nil)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, err
+}
+
+// commitQueue orders commits by commit date, newest first, as git log does.
+type commitQueue []*rawCommit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].commitDate.After(q[j].commitDate)
+}
+func (q commitQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*rawCommit)) }
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// walk visits the commits reachable from hash, newest first,
+// the commits in exclude and their parents are not visited.
+// It stops with the first error returned by visit.
+func (r *repository) walk(ctx context.Context, hash string, exclude map[string]bool, visit func(c *rawCommit) error) error {
+	seen := map[string]bool{hash: true}
+	q := &commitQueue{}
+	c, err := r.commit(hash)
+	if err != nil {
+		return err
+	}
+	heap.Push(q, c)
+
+	for q.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c := heap.Pop(q).(*rawCommit)
+		if exclude[c.hash] {
+			continue
+		}
+		if err := visit(c); err != nil {
+			return err
+		}
+		for _, p := range c.parents {
+			if seen[p] || exclude[p] {
+				continue
+			}
+			seen[p] = true
+			pc, err := r.commit(p)
+			if err != nil {
+				return err
+			}
+			heap.Push(q, pc)
+		}
+	}
+	return nil
+}
+
+// reachable returns the hashes of the commits reachable from hash.
+func (r *repository) reachable(ctx context.Context, hash string) (map[string]bool, error) {
+	ret := map[string]bool{}
+	err := r.walk(ctx, hash, nil, func(c *rawCommit) error {
+		ret[c.hash] = true
+		return nil
+	})
+	return ret, err
+}
+
+// tags returns the sorted tag names.
+func (r *repository) tags() []string {
+	tags := make([]string, 0)
+	for name := range r.refs("refs/tags/") {
+		tags = append(tags, strings.TrimPrefix(name, "refs/tags/"))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// tagsDetailed returns the tags sorted by name, with the commits they point to.
+func (r *repository) tagsDetailed() ([]driver.Tag, error) {
+	refs := r.refs("refs/tags/")
+	ret := make([]driver.Tag, 0, len(refs))
+	for _, name := range r.tags() {
+		hash := refs["refs/tags/"+name]
+		typ, data, err := r.object(hash)
+		if err != nil {
+			return ret, err
+		}
+		t := driver.Tag{Name: name, Revision: hash}
+		if typ == "tag" {
+			t.Annotated = true
+			var date time.Time
+			t.Tagger, t.TaggerEmail, date = parseSignature(header(data, "tagger"))
+			t.TaggerTime = normalizeDate(date)
+			if i := bytes.Index(data, []byte("\n\n")); i > -1 {
+				t.Message = strings.TrimSpace(string(data[i+2:]))
+			}
+			if t.Revision, err = r.peel(hash); err != nil {
+				return ret, err
+			}
+		}
+		if c, err := r.commit(t.Revision); err == nil {
+			t.Date = normalizeDate(c.authorDate)
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+// normalizeDate returns d as commit.ParseDate parses the dates printed by git,
+// so the locations match the git driver.
+func normalizeDate(d time.Time) time.Time {
+	if d.IsZero() {
+		return d
+	}
+	ret, _ := commit.ParseDate(d.Format(time.RFC3339))
+	return ret
+}
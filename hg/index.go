@@ -2,64 +2,153 @@
 package hg
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
 	"github.com/mh-cbon/verbose"
 )
 
 var logger = verbose.Auto()
 
-func getCmd(path string, args []string) (*exec.Cmd, error) {
-	bin, err := exec.LookPath("hg")
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for hg.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a hg driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("hg"))
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return nil, err
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
 	}
-	logger.Printf("%s %s (cwd=%s)", bin, args, path)
-	cmd := exec.Command(bin, args...)
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
 	return cmd, nil
 }
 
-// IsIt Test if given path is managed by hg with hg status
-func IsIt(path string) bool {
-	args := []string{"status"}
-	cmd, err := getCmd(path, args)
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	out, err := cmd.CombinedOutput()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs hg and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return false
+		return err
 	}
 
-	logger.Printf("out=%s", string(out))
-	return cmd.ProcessState != nil && cmd.ProcessState.Success()
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps hg failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "no repository found"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "already exists"):
+		return driver.ErrTagExists
+	case strings.Contains(stderr, "unknown revision"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
+// IsIt Test if given path is managed by hg with hg status
+func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Test if given path is managed by hg with hg status,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
+	args := []string{"status"}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir holds a .hg directory.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
+
+// IsRoot tells if dir holds a .hg directory.
+func (d Driver) IsRoot(dir string) bool {
+	s, err := os.Stat(filepath.Join(dir, ".hg"))
+	return err == nil && s.IsDir()
 }
 
 // List tags on given path
 func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists tags on given path
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
 	tags := make([]string, 0)
 
 	args := []string{"tags"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return tags, err
-	}
-
-	out, err := cmd.CombinedOutput()
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return tags, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	for _, v := range strings.Split(string(out), "\n") {
 		k := strings.Split(v, " ")
 		if len(k) > 0 && k[0] != "tip" {
@@ -69,73 +158,185 @@ func List(path string) ([]string, error) {
 	return tags, nil
 }
 
-// IsClean Check uncommited files with hg status -q
-func IsClean(path string) (bool, error) {
+// ListTagsDetailed lists the tags on given path with the commits they point to.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
 
-	args := []string{"status", "-q"}
-	cmd, err := getCmd(path, args)
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path,
+// the global tags are annotated by the changeset which added them to .hgtags,
+// the local tags are lightweight.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	ret := make([]driver.Tag, 0)
+
+	out, err := d.run(ctx, path, []string{"tags", "-T", "json"})
 	if err != nil {
-		return false, nil
+		return ret, err
+	}
+	var tags []jsonTag
+	if err = json.Unmarshal(out, &tags); err != nil {
+		return ret, fmt.Errorf("%w: %v", errUnexpectedLog, err)
+	}
+
+	if len(tags) == 0 || (len(tags) == 1 && tags[0].Tag == "tip") {
+		return ret, nil
 	}
 
-	out, err := cmd.CombinedOutput()
+	taggers := map[string]string{}
+	revset := "tag()"
+	for _, t := range tags {
+		if t.Type != "local" && t.Tag != "tip" {
+			out, err = d.run(ctx, path, []string{"annotate", "-c", "-r", "tip", ".hgtags"})
+			if err != nil {
+				return ret, err
+			}
+			taggers = ParseHgTagsAnnotate(string(out))
+			for _, node := range taggers {
+				revset += " or " + node
+			}
+			break
+		}
+	}
+
+	commits := map[string]commit.Commit{}
+	err = d.stream(ctx, path, []string{"log", "-T", "json", "-r", revset}, func(r io.Reader) error {
+		return walkHgJSONLogs(r, func(c commit.Commit) error {
+			commits[c.Revision] = c
+			return nil
+		})
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	for _, t := range tags {
+		if t.Tag == "tip" {
+			continue
+		}
+		tag := driver.Tag{Name: t.Tag, Revision: t.Node, Date: commits[t.Node].Time}
+		if node, ok := taggers[t.Tag]; ok && t.Type != "local" {
+			for rev, c := range commits {
+				if strings.HasPrefix(rev, node) {
+					tag.Annotated = true
+					tag.Tagger, tag.TaggerEmail, tag.TaggerTime = c.Author, c.Email, c.Time
+					tag.Message = c.Message
+				}
+			}
+		}
+		ret = append(ret, tag)
+	}
+	return ret, nil
+}
+
+type jsonTag struct {
+	Tag  string `json:"tag"`
+	Node string `json:"node"`
+	Type string `json:"type"`
+}
+
+// ParseHgTagsAnnotate parses hg annotate -c .hgtags output,
+// it returns the changeset which added the last line of each tag.
+func ParseHgTagsAnnotate(out string) map[string]string {
+	ret := map[string]string{}
+	lineRe := regexp.MustCompile(`^([0-9a-f]+): [0-9a-f]+ (.+)$`)
+	for _, line := range strings.Split(out, "\n") {
+		if res := lineRe.FindStringSubmatch(strings.TrimRight(line, "\r")); res != nil {
+			ret[strings.TrimSpace(res[2])] = res[1]
+		}
+	}
+	return ret
+}
+
+// IsClean Check uncommited files with hg status -q
+func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
+
+// IsCleanContext Check uncommited files with hg status -q
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"status", "-q"}
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	return len(string(out)) == 0, nil
 }
 
 // CreateTag Create given tag on path with the provided message
 func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
 
-	tags, err := List(path)
+// CreateTagContext Create given tag on path with the provided message
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+
+	tags, err := d.ListContext(ctx, path)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, "", err
 	}
 
 	if contains(tags, tag) {
-		return false, "", errors.New("Tag '" + tag + "' already exists")
+		return false, "", fmt.Errorf("%w: %s", driver.ErrTagExists, tag)
 	}
 
 	args := []string{"tag", tag}
 	if len(message) > 0 {
 		args = append(args, []string{"-m", message}...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return false, "", err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
-	return err == nil, string(out), nil
+	out, err := d.run(ctx, path, args)
+	return err == nil, string(out), err
 }
 
 // Add given file to hg on path
 func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to hg on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
 
 	args := []string{"add"}
 	if len(file) > 0 {
 		args = append(args, []string{file}...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
 // Commit given files with message on path
 func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext commits given files with message on path
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
 
 	if len(message) == 0 {
 		return errors.New("Message is required")
@@ -145,14 +346,7 @@ func Commit(path string, message string, files []string) error {
 	if len(files) > 0 {
 		args = append(args, files...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
@@ -167,7 +361,52 @@ func contains(s []string, e string) bool {
 
 // ListCommitsBetween List commits between two points
 func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two points
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
 	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while hg log prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points, while hg log prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommits calls fn with each commit selected by opts, while hg log prints them
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while hg log prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
 
 	if to == "HEAD" {
 		to = "tip"
@@ -176,39 +415,201 @@ func ListCommitsBetween(path string, since string, to string) ([]commit.Commit,
 		since = "0"
 	}
 
-	args := []string{"log", "-v"}
+	revs := []string{}
 	if len(since)+len(to) > 0 {
-		args = append(args, "-r", since+".."+to)
+		revs = append(revs, "-r", since+".."+to)
 	}
-	cmd, err := getCmd(path, args)
+
+	filter := opts.CommitFilter
+	if filter.Author != "" {
+		revs = append(revs, "-u", filter.Author)
+		filter.Author = ""
+	}
+	if date := hgDateRange(filter.After, filter.Before); date != "" {
+		revs = append(revs, "-d", date)
+		filter.After, filter.Before = time.Time{}, time.Time{}
+	}
+	if len(filter.Paths) > 0 {
+		revs = append(revs, "--")
+		revs = append(revs, filter.Paths...)
+		filter.Paths = nil
+	}
+	if n := filter.Limit(); n > 0 {
+		revs = append([]string{"-l", strconv.Itoa(n)}, revs...)
+	}
+	fn, err := filter.Filter(fn)
 	if err != nil {
-		return ret, err
+		return err
+	}
+
+	args := []string{"log", "-T", "json"}
+	if d.Files {
+		args = append(args, "--debug", "--copies")
 	}
+	args = append(args, revs...)
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	walked := false
+	walk := func(c commit.Commit) error {
+		walked = true
+		c.Complete()
+		return fn(c)
+	}
 
-	ret = ParseHgLogs(string(out))
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkHgJSONLogs(r, walk)
+	})
+	if err == nil || walked || (driver.Unrecognized(err) == false && errors.Is(err, errUnexpectedLog) == false) {
+		return driver.Stopped(err)
+	}
 
+	args = append([]string{"log", "-v"}, revs...)
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkHgLogs(r, walk)
+	})
+	return driver.Stopped(err)
+}
+
+// hgDateRange returns the hg log -d date range of the inclusive dates after and before.
+func hgDateRange(after time.Time, before time.Time) string {
+	layout := "2006-01-02 15:04:05 -0700"
+	switch {
+	case after.IsZero() == false && before.IsZero() == false:
+		return after.Format(layout) + " to " + before.Format(layout)
+	case after.IsZero() == false:
+		return ">" + after.Format(layout)
+	case before.IsZero() == false:
+		return "<" + before.Format(layout)
+	}
+	return ""
+}
+
+type jsonLog struct {
+	Node     string            `json:"node"`
+	Branch   string            `json:"branch"`
+	Parents  []string          `json:"parents"`
+	User     string            `json:"user"`
+	Date     []float64         `json:"date"`
+	Desc     string            `json:"desc"`
+	Added    []string          `json:"added"`
+	Modified []string          `json:"modified"`
+	Removed  []string          `json:"removed"`
+	Copies   map[string]string `json:"copies"`
+}
+
+const nullRevision = "0000000000000000000000000000000000000000"
+
+// ParseHgJSONLogs parses hg log -T json output to a list of commits,
+// the files are listed when the output was produced with --debug.
+func ParseHgJSONLogs(log string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := walkHgJSONLogs(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
 	return ret, err
 }
 
-// ParseHgLogs parses hg log output to a list of commits
+// errUnexpectedLog is returned when the output of hg log -T json can not be decoded.
+var errUnexpectedLog = errors.New("Unexpected hg log output")
+
+// walkHgJSONLogs calls fn with each commit of r while it is decoded, see ParseHgJSONLogs.
+func walkHgJSONLogs(r io.Reader, fn driver.WalkFunc) error {
+	dec := json.NewDecoder(r)
+	if t, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %v", errUnexpectedLog, err)
+	} else if t != json.Delim('[') {
+		return fmt.Errorf("%w: unexpected %v", errUnexpectedLog, t)
+	}
+
+	userRe := regexp.MustCompile(`^([^<]*)<([^>]+)>$`)
+	for dec.More() {
+		var l jsonLog
+		if err := dec.Decode(&l); err != nil {
+			return fmt.Errorf("%w: %v", errUnexpectedLog, err)
+		}
+		c := commit.Commit{Revision: l.Node, Author: strings.TrimSpace(l.User)}
+		if userRe.MatchString(c.Author) {
+			res := userRe.FindStringSubmatch(c.Author)
+			c.Author = strings.TrimSpace(res[1])
+			c.Email = res[2]
+		}
+		if len(l.Date) == 2 {
+			// the offset is in seconds west of UTC
+			zone := time.FixedZone("", -int(l.Date[1]))
+			c.Time = time.Unix(int64(l.Date[0]), 0).In(zone)
+		}
+		c.Message = strings.TrimSpace(l.Desc)
+		c.Branch = l.Branch
+		for _, p := range l.Parents {
+			if p != nullRevision {
+				c.Parents = append(c.Parents, p)
+			}
+		}
+		c.Files = jsonFiles(l)
+		c.Complete()
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %v", errUnexpectedLog, err)
+	}
+	return nil
+}
+
+// jsonFiles returns the changed files of l, sorted by path,
+// a copy whose source is removed is a rename.
+func jsonFiles(l jsonLog) []commit.File {
+	var files []commit.File
+	renamed := map[string]bool{}
+	for _, f := range l.Added {
+		if src, ok := l.Copies[f]; ok && contains(l.Removed, src) {
+			renamed[src] = true
+			files = append(files, commit.File{Action: commit.Renamed, Path: f, From: src})
+		} else {
+			files = append(files, commit.File{Action: commit.Added, Path: f})
+		}
+	}
+	for _, f := range l.Modified {
+		files = append(files, commit.File{Action: commit.Modified, Path: f})
+	}
+	for _, f := range l.Removed {
+		if renamed[f] == false {
+			files = append(files, commit.File{Action: commit.Deleted, Path: f})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// ParseHgLogs parses hg log -v output to a list of commits,
+// it is used when hg log -T json is not supported.
 func ParseHgLogs(log string) []commit.Commit {
 	ret := make([]commit.Commit, 0)
+	walkHgLogs(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret
+}
 
+// walkHgLogs calls fn with each commit of r, see ParseHgLogs.
+func walkHgLogs(r io.Reader, fn driver.WalkFunc) error {
 	commitRe := regexp.MustCompile(`^changeset:\s+[0-9]+:([^\s]+)$`)
 	authorRe := regexp.MustCompile(`^user:\s+([^<]+)\s+<([^>]+)>$`)
 	dateRe := regexp.MustCompile(`^date:\s*(.+)$`)
 	messageRe := regexp.MustCompile(`description:$`)
 	isInMessage := false
 	var c *commit.Commit
-	for _, line := range strings.Split(log, "\n") {
+	err := driver.Lines(r, func(line string) error {
 		line = strings.TrimSpace(line)
 		if commitRe.MatchString(line) {
 			if c != nil {
-				ret = append(ret, *c)
+				if err := fn(*c); err != nil {
+					return err
+				}
 			}
 			c = &commit.Commit{}
 			isInMessage = false
@@ -230,31 +631,35 @@ func ParseHgLogs(log string) []commit.Commit {
 				c.Message = c.Message + "\n" + line
 			}
 		}
+		return nil
+	})
+	if err == nil && c != nil && c.Revision != "" {
+		err = fn(*c)
 	}
-	if c != nil && c.Revision != "" {
-		ret = append(ret, *c)
-	}
-	return ret
+	return err
 }
 
 // GetRevisionTag Get revision of a tag
 func GetRevisionTag(path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(context.Background(), path, tag)
+}
+
+// GetRevisionTagContext is like GetRevisionTag, bounded by ctx.
+func GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(ctx, path, tag)
+}
+
+// GetRevisionTagContext Get revision of a tag
+func (d Driver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
 	rev := ""
 
 	args := []string{"tags"}
-	cmd, err := getCmd(path, args)
+	out, err := d.run(ctx, path, args)
 	if err != nil {
 		return tag, err
 	}
 
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Printf("err=%s", err)
-		return tag, err
-	}
-
 	revRe := regexp.MustCompile(`^[0-9]+[:;](.+)$`)
-	logger.Printf("out=%s", string(out))
 	for _, v := range strings.Split(string(out), "\n") {
 		k := strings.Split(v, " ")
 		if len(k) > 0 && k[0] == tag {
@@ -270,17 +675,19 @@ func GetRevisionTag(path string, tag string) (string, error) {
 
 // GetFirstRevision returns the first revision of the repository
 func GetFirstRevision(path string) (string, error) {
-	ret := ""
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
 
-	args := []string{"log", "-r", "first(0)", "--template", "{node}"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return ret, err
-	}
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+// GetFirstRevisionContext returns the first revision of the repository
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+
+	args := []string{"log", "-r", "first(0)", "--template", "{node}"}
+	out, err := d.run(ctx, path, args)
 
 	return strings.TrimSpace(string(out)), err
 }
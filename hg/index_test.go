@@ -0,0 +1,68 @@
+package hg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestParseHgJSONLogs(t *testing.T) {
+	log := `[
+ {
+  "bookmarks": [],
+  "branch": "default",
+  "date": [1483257600, -7200],
+  "desc": "tomate 1.0.2\n\nchangeset:   1:abc\nuser: x <y>",
+  "node": "065e4375921ce712e536b95109214b28e8e2c23e",
+  "parents": ["0000000000000000000000000000000000000000"],
+  "added": ["b", "c"],
+  "modified": ["d"],
+  "removed": ["a"],
+  "copies": {"c": "a"},
+  "phase": "draft",
+  "rev": 0,
+  "tags": [],
+  "user": "John Doe <john@example.com>"
+ }
+]`
+	commits, err := ParseHgJSONLogs(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(commits))
+	}
+	c := commits[0]
+	if c.Revision != "065e4375921ce712e536b95109214b28e8e2c23e" || c.Author != "John Doe" || c.Email != "john@example.com" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	if c.Message != "tomate 1.0.2\n\nchangeset:   1:abc\nuser: x <y>" {
+		t.Errorf("Unexpected message %q", c.Message)
+	}
+	if c.Branch != "default" || len(c.Parents) != 0 || c.Subject != "tomate 1.0.2" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	files := []commit.File{
+		{Action: commit.Added, Path: "b"},
+		{Action: commit.Renamed, Path: "c", From: "a"},
+		{Action: commit.Modified, Path: "d"},
+	}
+	if reflect.DeepEqual(c.Files, files) == false {
+		t.Errorf("Expected files %+v, got %+v", files, c.Files)
+	}
+	if c.Date != "2017-01-01T10:00:00+02:00" || c.Time.IsZero() {
+		t.Errorf("Unexpected date %q", c.Date)
+	}
+}
+
+func TestParseHgTagsAnnotate(t *testing.T) {
+	out := `1d2c3b4a5f6e: 065e4375921ce712e536b95109214b28e8e2c23e 1.0.0
+1d2c3b4a5f6e: 165e4375921ce712e536b95109214b28e8e2c23e notsemvertag
+7a8b9c0d1e2f: 265e4375921ce712e536b95109214b28e8e2c23e 1.0.0
+`
+	expected := map[string]string{"1.0.0": "7a8b9c0d1e2f", "notsemvertag": "1d2c3b4a5f6e"}
+	if got := ParseHgTagsAnnotate(out); reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
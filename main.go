@@ -2,14 +2,23 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/docopt/docopt.go"
+	"github.com/mh-cbon/go-repo-utils/changelog"
 	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/commit/conventional"
+	"github.com/mh-cbon/go-repo-utils/render"
 	"github.com/mh-cbon/go-repo-utils/repoutils"
 	"github.com/mh-cbon/verbose"
 )
@@ -23,11 +32,14 @@ func main() {
 	usage := `Go repo utils
 
 Usage:
-  go-repo-utils list-tags [-j|--json] [-a|--any] [-r|--reverse] [--path=<path>|-p <path>]
-  go-repo-utils list-commits [--path=<path>|-p <path>] [--since=<tag>|-s <tag>] [--until=<tag>|-u <tag>] [-r|--reverse] [--orderbydate]
-  go-repo-utils is-clean [-j|--json] [--path=<path>|-p=<path>]
-  go-repo-utils create-tag <tag> [-j|--json] [--path=<path>|-p <path>] [-m <message>]
-  go-repo-utils first-rev [-j|--json] [--path=<path>|-p <path>]
+  go-repo-utils list-tags [-j|--json] [--output=<output>] [--format=<template>] [-a|--any] [--constraint=<constraint>] [--no-prerelease] [--major=<major>] [--latest] [--prefix=<prefix>] [-r|--reverse] [-l|--long] [--path=<path>|-p <path>] [--timeout=<d>] [--vcs=<vcs>] [--native-git]
+  go-repo-utils list-commits [--path=<path>|-p <path>] [--since=<tag>|-s <tag>] [--until=<tag>|-u <tag>] [--prefix=<prefix>] [--file=<file>...] [--author=<author>] [--after=<date>] [--before=<date>] [--grep=<regexp>] [--trailer=<trailer>...] [-n <count>|--max-count=<count>] [--skip=<count>] [-r|--reverse] [--orderbydate] [--files] [--conventional] [-j|--json] [--jsonl] [--output=<output>] [--format=<template>] [--timeout=<d>] [--vcs=<vcs>] [--native-git]
+  go-repo-utils changelog [-j|--json] [--output=<output>] [--change-log] [--conventional] [--prefix=<prefix>] [--path=<path>|-p <path>] [--timeout=<d>] [--vcs=<vcs>] [--native-git]
+  go-repo-utils is-clean [-j|--json] [--output=<output>] [--format=<template>] [--path=<path>|-p=<path>] [--timeout=<d>] [--vcs=<vcs>]
+  go-repo-utils create-tag <tag> [-j|--json] [--output=<output>] [--format=<template>] [--prefix=<prefix>] [--path=<path>|-p <path>] [-m <message>] [--timeout=<d>] [--vcs=<vcs>]
+  go-repo-utils next-version [--bump=<bump>] [--preid=<preid>] [--prefix=<prefix>] [--create] [-m <message>] [-j|--json] [--output=<output>] [--format=<template>] [--path=<path>|-p <path>] [--timeout=<d>] [--vcs=<vcs>] [--native-git]
+  go-repo-utils first-rev [-j|--json] [--output=<output>] [--format=<template>] [--path=<path>|-p <path>] [--timeout=<d>] [--vcs=<vcs>] [--native-git]
+  go-repo-utils root [-j|--json] [--output=<output>] [--path=<path>|-p <path>] [--vcs=<vcs>]
   go-repo-utils -h | --help
   go-repo-utils -v | --version
 
@@ -37,20 +49,83 @@ Options:
   -p <c> --path=<c>     Path to lookup [default: cwd].
   -s <c> --since=<c>    Since tag, revision, expression.
   -u <c> --until=<c>    To tag, revision, expression.
-  -j --json             Print JSON encoded data.
+  -j --json             Print JSON encoded data, as --output=json.
+  --output=<output>     Print the result as text, json, jsonl, yaml or csv.
   -a --any              List all tags.
+  --constraint=<c>      Only semver tags matching the constraint, ex: ">=1.2, <2.0".
+  --no-prerelease       Only semver tags without prerelease.
+  --major=<major>       Only semver tags of this major version.
+  --latest              Only the greatest selected semver tag.
+  --prefix=<prefix>     Tags are this prefix followed by a version, ex: api/ for api/v1.4.0,
+                        a path.Match pattern such as */ selects any namespace.
   -r --reverse          Reverse tags ordering.
+  -l --long             List the tags with their revision, date, tagger and annotation.
   -m                    Message for the tag.
   --orderbydate         Order commits by date.
+  --bump=<bump>         Increment the version by major, minor, patch or prerelease,
+                        instead of the increment the commits call for.
+  --preid=<preid>       Identifier of the prerelease versions [default: rc].
+  --create              Create the tag of the next version.
+  --file=<file>         Only commits changing this file or directory, relative to the path.
+  --author=<author>     Only commits whose author name or email contains it, ignoring case.
+  --after=<date>        Only commits dated at or after it (2006-01-02, RFC 3339).
+  --before=<date>       Only commits dated at or before it, a day includes its end.
+  --grep=<regexp>       Only commits whose message matches the regular expression.
+  --trailer=<trailer>   Only commits with this trailer, as key or key:value, the value
+                        must contain value, both ignoring case.
+  -n <c> --max-count=<c>  Print at most c commits.
+  --skip=<c>            Skip the first c selected commits.
+  --files               List the files changed by each commit.
+  --conventional        Add the Conventional Commits fields of the messages,
+                        group the changelog changes by type.
+  --change-log          Print the changelog in the change.log format.
+  --jsonl               Print one JSON commit per line, as --output=jsonl.
+  --format=<template>   Print each item with this go text/template, or a preset
+                        for commits: oneline, short, medium.
+  --vcs=<vcs>           Use this vcs instead of detecting it (git, hg, bzr, svn, fossil,
+                        darcs, pijul).
+  --timeout=<d>         Abort vcs commands running longer than the duration (ex: 30s, 2m).
+  --native-git          Read git repositories without the git binary.
 
 Notes:
   list-tags     List only valid semver tags unless -a|--any options is provided.
+                --constraint, --no-prerelease, --major and --latest select semver tags only,
+                a prerelease matches a constraint only if the constraint has a prerelease.
   is-clean      Ignores untracked files.
   create-tag    With svn, it always create a new tag folder at /tags/<tag>.
+  --prefix      The prefix is stripped before the semver parsing, the tags keep their full name.
+                The versions given to --since, --until and create-tag are prefixed
+                unless they already have the prefix, a pattern prefix must match them.
+  root          Print the root of the working copy containing the path.
+  next-version  Print the version following the latest semver tag, given the commits since it:
+                major for a breaking change, minor for a feature, patch for the others,
+                as Conventional Commits. It keeps the prefix and the v of the tag.
+                A prerelease bump of a release applies the increment of the commits first.
+  changelog     Print the commits of each semver tag, and those after the last tag,
+                as markdown unless --json or --change-log is provided.
+  --vcs         When several vcs manage the path, the innermost working copy is used,
+                unless --vcs is provided.
   list-commits  Can receive an expression (hg, bzr), if it does not match a tag name.
-                Expression may be automatically adjusted at runtime if it is empty (svn,hg,bzr),
+                Expression may be automatically adjusted at runtime if it is empty (svn,hg,bzr,fossil),
                 or matching a tag name.
-                HEAD will be normalized given the target vcs (svn,hg,bzr).
+                HEAD will be normalized given the target vcs (svn,hg,bzr,fossil).
+                With darcs and pijul, since and until must be tag names.
+                The filters are given to the vcs when it supports them, fossil filters
+                a single --file.
+                With the text, jsonl or csv outputs, the commits are printed while the vcs
+                lists them, unless they are reordered with --reverse or --orderbydate.
+  --format      The template receives a tag name (list-tags), a tag (list-tags --long), a commit (list-commits),
+                the next version (next-version),
+                a bool (is-clean), a revision (first-rev) or the created tag (create-tag).
+                It can use the json and join functions, ex: {{.Revision}} {{.Author}}.
+  --output      The results are the list of tag names (list-tags), the list of tags
+                (list-tags --long), the list of commits
+                (list-commits), the list of releases (changelog), a bool (is-clean),
+                a revision (first-rev), the created tag (create-tag), {root, vcs} (root),
+                {previous, next, bump, commits} (next-version).
+                jsonl prints an item per line, csv a row per item with the struct
+                fields as columns, their lists and objects are JSON encoded.
+                list-commits prints json by default, the others text.
 
 Examples
   # list tags
@@ -59,11 +134,33 @@ Examples
   # list tags with json response
   go-repo-utils list-tags -j --path=/some/where
 
+  # list tags with their revision, date and tagger
+  go-repo-utils list-tags --long
+
+  # print the latest stable 1.x tag
+  go-repo-utils list-tags --major=1 --no-prerelease --latest
+
+  # list the commits of the api module since its tag api/v1.4.0
+  go-repo-utils list-commits --prefix=api/ --since=v1.4.0
+
   # check if a directory is clean
   go-repo-utis is-clean -p /some/where
 
   # create tag
   go-repo-utils create-tag 1.0.3 -m "tag message"
+
+  # create the tag of the next version
+  go-repo-utils next-version --create
+
+  # print the root of the repository
+  go-repo-utils root -p /some/where/sub/dir
+
+  # list commits, one per line
+  go-repo-utils list-commits --format=oneline
+  go-repo-utils list-commits --format="{{.ShortRevision}} {{.Author}} {{.Date}}"
+
+  # print the release notes grouped by conventional commit types
+  go-repo-utils changelog --conventional
 `
 
 	arguments, err := docopt.Parse(usage, nil, true, "Go repo utils - "+VERSION, false)
@@ -79,19 +176,45 @@ Examples
 		exitWithError(err)
 	}
 
-	vcs, err := repoutils.WhichVcs(path)
+	opts := []repoutils.Option{}
+	if vcs := getVcs(arguments); vcs != "" {
+		opts = append(opts, repoutils.WithVcs(vcs))
+	}
+	if timeout := getTimeout(arguments); timeout != "" {
+		d, err2 := time.ParseDuration(timeout)
+		exitWithError(err2)
+		opts = append(opts, repoutils.WithTimeout(d))
+	}
+
+	if isFiles(arguments) {
+		opts = append(opts, repoutils.WithFiles())
+	}
+	if isNativeGit(arguments) {
+		opts = append(opts, repoutils.WithNativeGit())
+	}
+
+	if cmd == "root" {
+		cmdRoot(arguments, path, opts)
+		return
+	}
+
+	repo, err := repoutils.Open(path, opts...)
 	exitWithError(err)
 
 	if cmd == "list-tags" {
-		cmdListTags(arguments, vcs, path)
+		cmdListTags(arguments, repo)
 	} else if cmd == "list-commits" {
-		cmdListCommits(arguments, vcs, path)
+		cmdListCommits(arguments, repo)
+	} else if cmd == "changelog" {
+		cmdChangelog(arguments, repo)
 	} else if cmd == "is-clean" {
-		cmdIsClean(arguments, vcs, path)
+		cmdIsClean(arguments, repo)
 	} else if cmd == "create-tag" {
-		cmdCreateTag(arguments, vcs, path)
+		cmdCreateTag(arguments, repo)
+	} else if cmd == "next-version" {
+		cmdNextVersion(arguments, repo)
 	} else if cmd == "first-rev" {
-		cmdFirstRev(arguments, vcs, path)
+		cmdFirstRev(arguments, repo)
 	} else if cmd == "" {
 		fmt.Println("Wrong usage: Missing command")
 		fmt.Println("")
@@ -103,55 +226,162 @@ Examples
 	}
 }
 
-func cmdIsClean(arguments map[string]interface{}, vcs string, path string) {
-	isClean, err := repoutils.IsClean(vcs, path)
+func cmdIsClean(arguments map[string]interface{}, repo *repoutils.Repo) {
+	isClean, err := repo.IsClean()
 	exitWithError(err)
 
-	if isJSON(arguments) {
-		jsoned, _ := json.Marshal(isClean)
-		fmt.Print(string(jsoned))
-	} else {
-		if isClean {
-			fmt.Println("yes")
-		} else {
-			fmt.Println("no")
+	printer := getPrinter(arguments, "text", render.Result{Column: "clean", Text: func(w io.Writer, v interface{}) error {
+		if v.(bool) {
+			_, err := fmt.Fprintln(w, "yes")
+			return err
+		}
+		_, err := fmt.Fprintln(w, "no")
+		return err
+	}})
+	exitWithError(printer.Print(isClean))
+	exitWithError(printer.Close())
+}
+
+func cmdListTags(arguments map[string]interface{}, repo *repoutils.Repo) {
+	if isLong(arguments) {
+		cmdListTagsDetailed(arguments, repo)
+		return
+	}
+
+	dirtyTags, err := repo.Tags()
+	exitWithError(err)
+
+	tags, err := selectTags(arguments, dirtyTags)
+	exitWithError(err)
+
+	printer := getPrinter(arguments, "text", render.Result{List: true, Column: "tag", Text: printLine})
+	for _, tag := range tags {
+		if len(tag) > 0 {
+			exitWithError(printer.Print(tag))
 		}
 	}
+	exitWithError(printer.Close())
 }
 
-func cmdListTags(arguments map[string]interface{}, vcs string, path string) {
-	tags := make([]string, 0)
-	dirtyTags, err := repoutils.List(vcs, path)
+// cmdListTagsDetailed lists the tags as list-tags does, with their details.
+func cmdListTagsDetailed(arguments map[string]interface{}, repo *repoutils.Repo) {
+	dirtyTags, err := repo.TagsDetailed()
+	exitWithError(err)
+
+	names := make([]string, 0)
+	byName := map[string]repoutils.Tag{}
+	for _, tag := range dirtyTags {
+		names = append(names, tag.Name)
+		byName[tag.Name] = tag
+	}
+	names, err = selectTags(arguments, names)
 	exitWithError(err)
 
+	printer := getPrinter(arguments, "text", render.Result{List: true, Text: printTag})
+	for _, name := range names {
+		exitWithError(printer.Print(byName[name]))
+	}
+	exitWithError(printer.Close())
+}
+
+// selectTags filters and sorts the tags given the list-tags options.
+func selectTags(arguments map[string]interface{}, dirtyTags []string) ([]string, error) {
+	prefix := getTagPrefix(arguments)
+	tags := make([]string, 0)
 	if isAny(arguments) == false {
-		tags = repoutils.FilterSemverTags(dirtyTags)
+		tags = prefix.FilterSemverTags(dirtyTags)
 	} else {
 		tags = append(tags, dirtyTags...)
 	}
 
-	tags = repoutils.SortSemverTags(tags)
+	if constraint, ok := arguments["--constraint"].(string); ok {
+		var err error
+		tags, err = prefix.FilterTagsByConstraint(tags, constraint)
+		if err != nil {
+			return tags, err
+		}
+	}
+	if isNoPrerelease(arguments) {
+		tags = prefix.FilterStableTags(tags)
+	}
+	if major, ok := arguments["--major"].(string); ok {
+		n, err := strconv.ParseInt(major, 10, 64)
+		if err != nil {
+			return tags, fmt.Errorf("invalid major version %q: %w", major, err)
+		}
+		tags = prefix.FilterTagsByMajor(tags, n)
+	}
+
+	if isLatest(arguments) {
+		if latest := prefix.LatestSemverTag(tags); latest != "" {
+			return []string{latest}, nil
+		}
+		return []string{}, nil
+	}
+
+	tags = prefix.SortSemverTags(tags)
 
 	if isReversed(arguments) {
 		tags = repoutils.ReverseTags(tags)
 	}
+	return tags, nil
+}
 
-	if isJSON(arguments) {
-		jsoned, _ := json.Marshal(tags)
-		fmt.Print(string(jsoned))
-	} else {
-		for _, tag := range tags {
-			if len(tag) > 0 {
-				fmt.Println(tag)
-			}
+// printTag prints the name, revision, date and tagger of a tag, separated by tabs.
+func printTag(w io.Writer, v interface{}) error {
+	tag := v.(repoutils.Tag)
+	date := ""
+	if tag.Date.IsZero() == false {
+		date = tag.Date.Format(time.RFC3339)
+	}
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tag.Name, tag.Revision, date, tag.Tagger)
+	return err
+}
+
+func cmdNextVersion(arguments map[string]interface{}, repo *repoutils.Repo) {
+	opts := repoutils.NextVersionOptions{Prefix: getTagPrefix(arguments)}
+	if bump, ok := arguments["--bump"].(string); ok {
+		b, err := repoutils.ParseBump(bump)
+		exitWithError(err)
+		opts.Bump = b
+	}
+	if preid, ok := arguments["--preid"].(string); ok {
+		opts.Preid = preid
+	}
+
+	next, err := repo.NextVersion(opts)
+	exitWithError(err)
+
+	if isCreate(arguments) {
+		if next.Bump == repoutils.BumpNone {
+			exitWithError(fmt.Errorf("Nothing to release since %s", next.Previous))
+		}
+		message := getMessage(arguments)
+		if len(message) == 0 {
+			message = "tag: " + next.Next
+		}
+		_, out, err := repo.CreateTag(next.Next, message)
+		if err != nil {
+			log.Println(out)
+			exitWithError(err)
 		}
 	}
+
+	printer := getPrinter(arguments, "text", render.Result{Text: func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprintln(w, v.(repoutils.VersionBump).Next)
+		return err
+	}})
+	exitWithError(printer.Print(next))
+	exitWithError(printer.Close())
 }
 
-func cmdListCommits(arguments map[string]interface{}, vcs string, path string) {
+func cmdListCommits(arguments map[string]interface{}, repo *repoutils.Repo) {
 
-	since := getSince(arguments)
-	until := getUntil(arguments)
+	prefix := getTagPrefix(arguments)
+	since, err := prefixVersion(prefix, getSince(arguments))
+	exitWithError(err)
+	until, err := prefixVersion(prefix, getUntil(arguments))
+	exitWithError(err)
 	reversed := isReversed(arguments)
 	orderbydate := isOrderByDate(arguments)
 
@@ -159,7 +389,27 @@ func cmdListCommits(arguments map[string]interface{}, vcs string, path string) {
 		until = "HEAD"
 	}
 
-	commits, err := repoutils.ListCommitsBetween(vcs, path, since, until)
+	filter, err := getCommitFilter(arguments)
+	exitWithError(err)
+	opts := repoutils.ListOptions{Since: since, To: until, CommitFilter: filter}
+
+	withConventional := isConventional(arguments)
+
+	medium := template.Must(template.New("medium").Funcs(templateFuncs).Parse(formats["medium"]))
+	printer := getPrinter(arguments, "json", render.Result{List: true, Text: func(w io.Writer, v interface{}) error {
+		return printTemplate(w, medium, v)
+	}})
+	printCommit := func(c commit.Commit) error {
+		return printer.Print(outputCommit(c, withConventional))
+	}
+
+	if reversed == false && orderbydate == false {
+		exitWithError(repo.WalkCommitsWith(opts, printCommit))
+		exitWithError(printer.Close())
+		return
+	}
+
+	commits, err := repo.ListCommits(opts)
 	exitWithError(err)
 
 	if orderbydate {
@@ -172,50 +422,146 @@ func cmdListCommits(arguments map[string]interface{}, vcs string, path string) {
 		commit.Commits(commits).Reverse()
 	}
 
-	jsoned, err := json.MarshalIndent(commits, "", "  ")
+	for _, c := range commits {
+		exitWithError(printCommit(c))
+	}
+	exitWithError(printer.Close())
+}
+
+// conventionalCommit is a commit printed with its conventional commit fields.
+type conventionalCommit struct {
+	commit.Commit
+	Conventional *conventional.Commit `json:"conventional,omitempty"`
+}
+
+// outputCommit returns the value printed for c, with its conventional fields when asked
+// and its message follows the convention.
+func outputCommit(c commit.Commit, withConventional bool) interface{} {
+	if withConventional == false {
+		return c
+	}
+	ret := conventionalCommit{Commit: c}
+	ret.Conventional, _ = conventional.Parse(c.Message)
+	return ret
+}
+
+func cmdChangelog(arguments map[string]interface{}, repo *repoutils.Repo) {
+	tags, err := repo.Tags()
 	exitWithError(err)
-	fmt.Print(string(jsoned))
+	prefix := getTagPrefix(arguments)
+	tags = prefix.SortSemverTags(prefix.FilterSemverTags(tags))
+
+	releases, err := changelog.Build(repo, tags, isConventional(arguments))
+	exitWithError(err)
+
+	write := changelog.WriteMarkdown
+	if isChangeLog(arguments) {
+		write = changelog.WriteChangeLog
+	}
+	printer := getPrinter(arguments, "text", render.Result{List: true, Text: func(w io.Writer, v interface{}) error {
+		return write(w, []changelog.Release{v.(changelog.Release)})
+	}})
+	for _, r := range releases {
+		exitWithError(printer.Print(r))
+	}
+	exitWithError(printer.Close())
 }
 
-func cmdCreateTag(arguments map[string]interface{}, vcs string, path string) {
+func cmdCreateTag(arguments map[string]interface{}, repo *repoutils.Repo) {
 
 	tag := getTag(arguments)
 	if len(tag) == 0 {
 		exitWithError(errors.New("Missing tag value"))
 	}
+	tag, err := getTagPrefix(arguments).Tag(tag)
+	exitWithError(err)
 	message := getMessage(arguments)
 	if len(message) == 0 {
 		message = "tag: " + tag
 	}
 
-	_, out, err := repoutils.CreateTag(vcs, path, tag, message)
+	printer := getPrinter(arguments, "text", render.Result{Column: "tag", Text: func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprintln(w, "done")
+		return err
+	}})
+
+	_, out, err := repo.CreateTag(tag, message)
 	if err != nil {
 		log.Println(out)
 		exitWithError(err)
 	}
 
-	if isJSON(arguments) {
-		jsoned, _ := json.Marshal(true)
-		fmt.Print(string(jsoned))
-	} else {
-		fmt.Println("done")
-	}
+	exitWithError(printer.Print(tag))
+	exitWithError(printer.Close())
 }
 
-func cmdFirstRev(arguments map[string]interface{}, vcs string, path string) {
+func cmdFirstRev(arguments map[string]interface{}, repo *repoutils.Repo) {
+
+	printer := getPrinter(arguments, "text", render.Result{Column: "revision", Text: printLine})
 
-	out, err := repoutils.GetFirstRevision(vcs, path)
+	out, err := repo.FirstRevision()
 	if err != nil {
 		log.Println(out)
 		exitWithError(err)
 	}
 
+	exitWithError(printer.Print(out))
+	exitWithError(printer.Close())
+}
+
+// rootResult is the result of the root command.
+type rootResult struct {
+	Root string `json:"root"`
+	Vcs  string `json:"vcs"`
+}
+
+func cmdRoot(arguments map[string]interface{}, path string, opts []repoutils.Option) {
+
+	printer := getPrinter(arguments, "text", render.Result{Text: func(w io.Writer, v interface{}) error {
+		return printLine(w, v.(rootResult).Root)
+	}})
+
+	root, vcs, err := repoutils.FindRoot(path, opts...)
+	exitWithError(err)
+
+	exitWithError(printer.Print(rootResult{Root: root, Vcs: vcs}))
+	exitWithError(printer.Close())
+}
+
+// printLine prints v followed by a line feed.
+func printLine(w io.Writer, v interface{}) error {
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
+
+// getPrinter returns the printer of the --output format, defaultOutput when it is not provided.
+// -j and --jsonl are the json and jsonl formats, --format prints the text format with a template.
+func getPrinter(arguments map[string]interface{}, defaultOutput string, r render.Result) *render.Printer {
+	output := defaultOutput
 	if isJSON(arguments) {
-		jsoned, _ := json.Marshal(true)
-		fmt.Print(string(jsoned))
-	} else {
-		fmt.Println(string(out))
+		output = "json"
+	} else if isJSONL(arguments) {
+		output = "jsonl"
+	}
+	if o, ok := arguments["--output"].(string); ok {
+		output = o
 	}
+
+	tpl, err := getTemplate(arguments)
+	exitWithError(err)
+	if tpl != nil {
+		if _, ok := arguments["--output"].(string); ok && output != "text" {
+			exitWithError(fmt.Errorf("--format prints the text output, not %s", output))
+		}
+		output = "text"
+		r.Text = func(w io.Writer, v interface{}) error {
+			return printTemplate(w, tpl, v)
+		}
+	}
+
+	printer, err := render.New(os.Stdout, output, r)
+	exitWithError(err)
+	return printer
 }
 
 func getCommand(arguments map[string]interface{}) string {
@@ -224,7 +570,10 @@ func getCommand(arguments map[string]interface{}) string {
 		"is-clean",
 		"create-tag",
 		"list-commits",
+		"changelog",
 		"first-rev",
+		"next-version",
+		"root",
 	}
 	for _, cmd := range cmds {
 		if p, ok := arguments[cmd]; ok {
@@ -279,6 +628,139 @@ func getUntil(arguments map[string]interface{}) string {
 	return tag
 }
 
+func getTagPrefix(arguments map[string]interface{}) repoutils.TagPrefix {
+	prefix := ""
+	if p, ok := arguments["--prefix"].(string); ok {
+		prefix = p
+	}
+	return repoutils.TagPrefix(prefix)
+}
+
+// prefixVersion returns tag with the prefix when it is a version, such as v1.4.0,
+// the revisions and expressions are returned as is.
+func prefixVersion(prefix repoutils.TagPrefix, tag string) (string, error) {
+	if _, err := repoutils.TagPrefix("").Parse(tag); err != nil {
+		return tag, nil
+	}
+	return prefix.Tag(tag)
+}
+
+func getVcs(arguments map[string]interface{}) string {
+	vcs := ""
+	if v, ok := arguments["--vcs"].(string); ok {
+		vcs = v
+	}
+	return vcs
+}
+
+func getTimeout(arguments map[string]interface{}) string {
+	timeout := ""
+	if t, ok := arguments["--timeout"].(string); ok {
+		timeout = t
+	}
+	return timeout
+}
+
+func getCommitFilter(arguments map[string]interface{}) (repoutils.CommitFilter, error) {
+	filter := repoutils.CommitFilter{}
+	if files, ok := arguments["--file"].([]string); ok {
+		filter.Paths = files
+	}
+	if author, ok := arguments["--author"].(string); ok {
+		filter.Author = author
+	}
+	if message, ok := arguments["--grep"].(string); ok {
+		filter.Message = message
+	}
+	if trailers, ok := arguments["--trailer"].([]string); ok {
+		for _, trailer := range trailers {
+			filter.Trailers = append(filter.Trailers, parseTrailer(trailer))
+		}
+	}
+	if after, ok := arguments["--after"].(string); ok {
+		d, err := commit.ParseDate(after)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = d
+	}
+	if before, ok := arguments["--before"].(string); ok {
+		d, err := commit.ParseDate(before)
+		if err != nil {
+			return filter, err
+		}
+		if _, err := time.Parse("2006-01-02", before); err == nil {
+			d = d.Add(24*time.Hour - time.Nanosecond)
+		}
+		filter.Before = d
+	}
+	for _, arg := range []string{"--max-count", "-n"} {
+		if count, ok := arguments[arg].(string); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil {
+				return filter, err
+			}
+			filter.MaxCount = n
+		}
+	}
+	if skip, ok := arguments["--skip"].(string); ok {
+		n, err := strconv.Atoi(skip)
+		if err != nil {
+			return filter, err
+		}
+		filter.Skip = n
+	}
+	return filter, nil
+}
+
+// parseTrailer parses a key or key:value trailer filter, key=value is also accepted.
+func parseTrailer(trailer string) commit.Trailer {
+	i := strings.IndexAny(trailer, ":=")
+	if i < 0 {
+		return commit.Trailer{Key: strings.TrimSpace(trailer)}
+	}
+	return commit.Trailer{Key: strings.TrimSpace(trailer[:i]), Value: strings.TrimSpace(trailer[i+1:])}
+}
+
+// formats are the --format presets for commits.
+var formats = map[string]string{
+	"oneline": `{{.ShortRevision}} {{.Subject}}`,
+	"short":   "commit {{.Revision}}\nAuthor: {{.Author}} <{{.Email}}>\n\n    {{.Subject}}\n",
+	"medium":  "commit {{.Revision}}\nAuthor: {{.Author}} <{{.Email}}>\nDate:   {{.Date}}\n\n    {{.Subject}}\n{{if .Body}}\n    {{.Body}}\n{{end}}",
+}
+
+// templateFuncs are the functions the --format templates can use.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": strings.Join,
+}
+
+// getTemplate returns the --format template, nil when it is not provided.
+func getTemplate(arguments map[string]interface{}) (*template.Template, error) {
+	format, ok := arguments["--format"].(string)
+	if ok == false {
+		return nil, nil
+	}
+	if preset, ok := formats[format]; ok {
+		format = preset
+	}
+	return template.New("format").Funcs(templateFuncs).Parse(format)
+}
+
+// printTemplate prints v with tpl, followed by a line feed.
+func printTemplate(w io.Writer, tpl *template.Template, v interface{}) error {
+	var b bytes.Buffer
+	if err := tpl.Execute(&b, v); err != nil {
+		return err
+	}
+	b.WriteString("\n")
+	_, err := b.WriteTo(w)
+	return err
+}
+
 func getMessage(arguments map[string]interface{}) string {
 	message := ""
 	if mess, ok := arguments["-m"].(string); ok {
@@ -299,6 +781,42 @@ func isAny(arguments map[string]interface{}) bool {
 	return any
 }
 
+func isLong(arguments map[string]interface{}) bool {
+	long := false
+	if isIt, ok := arguments["--long"].(bool); ok {
+		long = isIt
+	} else {
+		if isL, ok := arguments["-l"].(bool); ok {
+			long = isL
+		}
+	}
+	return long
+}
+
+func isNoPrerelease(arguments map[string]interface{}) bool {
+	noPrerelease := false
+	if isIt, ok := arguments["--no-prerelease"].(bool); ok {
+		noPrerelease = isIt
+	}
+	return noPrerelease
+}
+
+func isLatest(arguments map[string]interface{}) bool {
+	latest := false
+	if isIt, ok := arguments["--latest"].(bool); ok {
+		latest = isIt
+	}
+	return latest
+}
+
+func isCreate(arguments map[string]interface{}) bool {
+	create := false
+	if isIt, ok := arguments["--create"].(bool); ok {
+		create = isIt
+	}
+	return create
+}
+
 func isJSON(arguments map[string]interface{}) bool {
 	json := false
 	if isIt, ok := arguments["--json"].(bool); ok {
@@ -311,6 +829,30 @@ func isJSON(arguments map[string]interface{}) bool {
 	return json
 }
 
+func isConventional(arguments map[string]interface{}) bool {
+	conventional := false
+	if isIt, ok := arguments["--conventional"].(bool); ok {
+		conventional = isIt
+	}
+	return conventional
+}
+
+func isChangeLog(arguments map[string]interface{}) bool {
+	changeLog := false
+	if isIt, ok := arguments["--change-log"].(bool); ok {
+		changeLog = isIt
+	}
+	return changeLog
+}
+
+func isJSONL(arguments map[string]interface{}) bool {
+	jsonl := false
+	if isIt, ok := arguments["--jsonl"].(bool); ok {
+		jsonl = isIt
+	}
+	return jsonl
+}
+
 func isReversed(arguments map[string]interface{}) bool {
 	reverse := false
 	if isReverse, ok := arguments["--reverse"].(bool); ok {
@@ -323,6 +865,22 @@ func isReversed(arguments map[string]interface{}) bool {
 	return reverse
 }
 
+func isFiles(arguments map[string]interface{}) bool {
+	files := false
+	if f, ok := arguments["--files"].(bool); ok {
+		files = f
+	}
+	return files
+}
+
+func isNativeGit(arguments map[string]interface{}) bool {
+	nativeGit := false
+	if n, ok := arguments["--native-git"].(bool); ok {
+		nativeGit = n
+	}
+	return nativeGit
+}
+
 func isOrderByDate(arguments map[string]interface{}) bool {
 	orderbydate := false
 	if isIt, ok := arguments["--orderbydate"].(bool); ok {
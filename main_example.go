@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/mh-cbon/go-repo-utils/repoutils"
 )
@@ -13,18 +14,20 @@ func ExampleMain() {
 
 	path := "path/to/folder"
 
-	vcs, err := repoutils.WhichVcs(path)
+	repo, err := repoutils.Open(path, repoutils.WithTimeout(time.Minute))
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
 
-	tags, _ := repoutils.List(vcs, path)
+	fmt.Println(repo.Vcs())
+
+	tags, _ := repo.Tags()
 	fmt.Println(tags)
 
-	isClean, _ := repoutils.IsClean(vcs, path)
+	isClean, _ := repo.IsClean()
 	fmt.Println(isClean)
 
-	ok, _, _ := repoutils.CreateTag(vcs, path, "1.0.3", "the new tag")
+	ok, _, _ := repo.CreateTag("1.0.3", "the new tag")
 	fmt.Println(ok)
 }
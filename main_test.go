@@ -51,9 +51,12 @@ func TestGit(t *testing.T) {
 	DoListTags("/home/vagrant/git", tt)
 	DoListCommits("/home/vagrant/git", tt)
 	DoListCommitsBetween("/home/vagrant/git", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/git", tt)
 	DoListCommitsSinceBeginning("/home/vagrant/git", tt)
 	DoSortCommitsDesc("/home/vagrant/git", tt)
 	DoTestFirstRevGit("/home/vagrant/git", tt)
+	DoTestRoot("/home/vagrant/git", "git", tt)
+	DoTestRootFromSubDir("/home/vagrant/git", tt)
 }
 
 func TestHg(t *testing.T) {
@@ -73,9 +76,11 @@ func TestHg(t *testing.T) {
 	DoListTags("/home/vagrant/hg", tt)
 	DoListCommits("/home/vagrant/hg", tt)
 	DoListCommitsBetween("/home/vagrant/hg", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/hg", tt)
 	DoListCommitsSinceBeginning("/home/vagrant/hg", tt)
 	DoSortCommitsDesc("/home/vagrant/hg", tt)
 	DoTestFirstRevHg("/home/vagrant/hg", tt)
+	DoTestRoot("/home/vagrant/hg", "hg", tt)
 }
 
 func TestSvn(t *testing.T) {
@@ -95,9 +100,11 @@ func TestSvn(t *testing.T) {
 	DoListTags("/home/vagrant/svn_work", tt)
 	DoListCommits("/home/vagrant/svn_work", tt)
 	DoListCommitsBetween("/home/vagrant/svn_work", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/svn_work", tt)
 	DoListCommitsSinceBeginning("/home/vagrant/svn_work", tt)
 	DoSortCommitsDesc("/home/vagrant/svn_work", tt)
 	DoTestFirstRevSvn("/home/vagrant/svn_work", tt)
+	DoTestRoot("/home/vagrant/svn_work", "svn", tt)
 }
 
 func TestBzr(t *testing.T) {
@@ -117,9 +124,80 @@ func TestBzr(t *testing.T) {
 	DoListTags("/home/vagrant/bzr", tt)
 	DoListCommits("/home/vagrant/bzr", tt)
 	DoListCommitsBetween("/home/vagrant/bzr", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/bzr", tt)
 	DoListCommitsSinceBeginning("/home/vagrant/bzr", tt)
 	DoSortCommitsDesc("/home/vagrant/bzr", tt)
 	DoTestFirstRevBzr("/home/vagrant/bzr", tt)
+	DoTestRoot("/home/vagrant/bzr", "bzr", tt)
+}
+
+func TestFossil(t *testing.T) {
+	tt := &TestingExiter{t}
+	DoTestFolderUnderVcs("/home/vagrant/fossil", tt)
+	DoTestFolderUnderVcsAsJSON("/home/vagrant/fossil", tt)
+	DoTestFolderUnderVcsAny("/home/vagrant/fossil", tt)
+	DoTestFolderUnderVcsAnyReversed("/home/vagrant/fossil", tt)
+	DoTestFolderIsClean("/home/vagrant/fossil", tt)
+	DoTestFolderIsCleanJSON("/home/vagrant/fossil", tt)
+	DoTestFolderIsDirty("/home/vagrant/fossil_dirty", tt)
+	DoTestFolderIsCleanEvenWithUntrackedFiles("/home/vagrant/fossil_untracked", tt)
+	DoCreateTag("/home/vagrant/fossil", tt)
+	DoCreateTagWithMessage("/home/vagrant/fossil", tt)
+	DoFailCreateTag("/home/vagrant/fossil", tt)
+	DoFailCreateTagMissTagName("/home/vagrant/fossil", tt)
+	DoListTags("/home/vagrant/fossil", tt)
+	DoListCommits("/home/vagrant/fossil", tt)
+	DoListCommitsBetween("/home/vagrant/fossil", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/fossil", tt)
+	DoListCommitsSinceBeginning("/home/vagrant/fossil", tt)
+	DoSortCommitsDesc("/home/vagrant/fossil", tt)
+	DoTestRoot("/home/vagrant/fossil", "fossil", tt)
+}
+
+func TestDarcs(t *testing.T) {
+	tt := &TestingExiter{t}
+	DoTestFolderUnderVcs("/home/vagrant/darcs", tt)
+	DoTestFolderUnderVcsAsJSON("/home/vagrant/darcs", tt)
+	DoTestFolderUnderVcsAny("/home/vagrant/darcs", tt)
+	DoTestFolderUnderVcsAnyReversed("/home/vagrant/darcs", tt)
+	DoTestFolderIsClean("/home/vagrant/darcs", tt)
+	DoTestFolderIsCleanJSON("/home/vagrant/darcs", tt)
+	DoTestFolderIsDirty("/home/vagrant/darcs_dirty", tt)
+	DoTestFolderIsCleanEvenWithUntrackedFiles("/home/vagrant/darcs_untracked", tt)
+	DoCreateTag("/home/vagrant/darcs", tt)
+	DoCreateTagWithMessage("/home/vagrant/darcs", tt)
+	DoFailCreateTag("/home/vagrant/darcs", tt)
+	DoFailCreateTagMissTagName("/home/vagrant/darcs", tt)
+	DoListTags("/home/vagrant/darcs", tt)
+	DoListCommits("/home/vagrant/darcs", tt)
+	DoListCommitsBetween("/home/vagrant/darcs", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/darcs", tt)
+	DoListCommitsSinceBeginning("/home/vagrant/darcs", tt)
+	DoSortCommitsDesc("/home/vagrant/darcs", tt)
+	DoTestRoot("/home/vagrant/darcs", "darcs", tt)
+}
+
+func TestPijul(t *testing.T) {
+	tt := &TestingExiter{t}
+	DoTestFolderUnderVcs("/home/vagrant/pijul", tt)
+	DoTestFolderUnderVcsAsJSON("/home/vagrant/pijul", tt)
+	DoTestFolderUnderVcsAny("/home/vagrant/pijul", tt)
+	DoTestFolderUnderVcsAnyReversed("/home/vagrant/pijul", tt)
+	DoTestFolderIsClean("/home/vagrant/pijul", tt)
+	DoTestFolderIsCleanJSON("/home/vagrant/pijul", tt)
+	DoTestFolderIsDirty("/home/vagrant/pijul_dirty", tt)
+	DoTestFolderIsCleanEvenWithUntrackedFiles("/home/vagrant/pijul_untracked", tt)
+	DoCreateTag("/home/vagrant/pijul", tt)
+	DoCreateTagWithMessage("/home/vagrant/pijul", tt)
+	DoFailCreateTag("/home/vagrant/pijul", tt)
+	DoFailCreateTagMissTagName("/home/vagrant/pijul", tt)
+	DoListTags("/home/vagrant/pijul", tt)
+	DoListCommits("/home/vagrant/pijul", tt)
+	DoListCommitsBetween("/home/vagrant/pijul", tt)
+	DoListCommitsSinceListedTag("/home/vagrant/pijul", tt)
+	DoListCommitsSinceBeginning("/home/vagrant/pijul", tt)
+	DoSortCommitsDesc("/home/vagrant/pijul", tt)
+	DoTestRoot("/home/vagrant/pijul", "pijul", tt)
 }
 
 func TestPathArgs(t *testing.T) {
@@ -167,7 +245,7 @@ func DoTestFolderUnderVcsWithPath(path string, t Errorer) {
 	args := []string{"list-tags", "-p", path}
 	out := ExecSuccessCommand(t, cmd, "/home", args)
 
-	expectedOut := "1.0.0\n1.0.2\n1.0.3\n1.0.4\n"
+	expectedOut := "v1.0.0\nv1.0.2\n1.0.3\n1.0.4\n"
 	if out != expectedOut {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
@@ -200,7 +278,7 @@ func DoListTags(path string, t Errorer) {
 	args := []string{"list-tags"}
 	out := ExecSuccessCommand(t, cmd, path, args)
 
-	expectedOut := "1.0.0\n1.0.2\n1.0.3\n1.0.4\n"
+	expectedOut := "v1.0.0\nv1.0.2\n1.0.3\n1.0.4\n"
 	if out != expectedOut {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
@@ -252,7 +330,7 @@ func DoTestFolderUnderVcs(path string, t Errorer) {
 	args := []string{"list-tags"}
 	out := ExecSuccessCommand(t, cmd, path, args)
 
-	expectedOut := "1.0.0\n1.0.2\n"
+	expectedOut := "v1.0.0\nv1.0.2\n"
 	if out != expectedOut {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
@@ -263,7 +341,7 @@ func DoTestFolderUnderVcsAsJSON(path string, t Errorer) {
 	args := []string{"list-tags", "-j"}
 	out := ExecSuccessCommand(t, cmd, path, args)
 
-	expectedOut := "[\"1.0.0\",\"1.0.2\"]"
+	expectedOut := "[\"v1.0.0\",\"v1.0.2\"]"
 	if out != expectedOut {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
@@ -274,7 +352,7 @@ func DoTestFolderUnderVcsAny(path string, t Errorer) {
 	args := []string{"list-tags", "-a"}
 	out := ExecSuccessCommand(t, cmd, path, args)
 
-	expectedOut := "1.0.0\n1.0.2\nnotsemvertag\n"
+	expectedOut := "v1.0.0\nv1.0.2\nnotsemvertag\n"
 	if out != expectedOut {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
@@ -285,7 +363,7 @@ func DoTestFolderUnderVcsAnyReversed(path string, t Errorer) {
 	args := []string{"list-tags", "-a", "-r"}
 	out := ExecSuccessCommand(t, cmd, path, args)
 
-	expectedOut := "notsemvertag\n1.0.2\n1.0.0\n"
+	expectedOut := "notsemvertag\nv1.0.2\nv1.0.0\n"
 	if out != expectedOut {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
@@ -394,6 +472,44 @@ func DoListCommitsBetween(path string, t Errorer) {
 	}
 }
 
+func DoListCommitsSinceListedTag(path string, t Errorer) {
+	cmd := "/vagrant/build/go-repo-utils"
+	args := []string{"list-tags", "-j"}
+	out := ExecSuccessCommand(t, cmd, path, args)
+
+	var tags []string
+	err := json.Unmarshal([]byte(out), &tags)
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%q\n", err)
+	}
+	if len(tags) < 2 || tags[0] != "v1.0.0" || tags[1] != "v1.0.2" {
+		t.Errorf("Expected tags to start with %q, got tags=%q\n", []string{"v1.0.0", "v1.0.2"}, tags)
+		return
+	}
+
+	// the listed tags are given as is to list-commits
+	args = []string{"list-commits", "--since", tags[1], "--until", tags[0]}
+	out = ExecSuccessCommand(t, cmd, path, args)
+
+	var commits []commit.Commit
+	err = json.Unmarshal([]byte(out), &commits)
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%q\n", err)
+	}
+
+	found := false
+	message := "tomate 1.0.0"
+	for _, c := range commits {
+		if c.Message == message {
+			found = true
+		}
+	}
+
+	if found == false {
+		t.Errorf("Expected commits to contain an entry with message=%q, but it was not found\n", message)
+	}
+}
+
 func DoListCommitsSinceBeginning(path string, t Errorer) {
 	cmd := "/vagrant/build/go-repo-utils"
 	args := []string{"list-commits", "--until", "v1.0.0"}
@@ -489,6 +605,27 @@ func DoTestFirstRevSvn(path string, t Errorer) {
 		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
 	}
 }
+
+func DoTestRoot(path string, vcs string, t Errorer) {
+	cmd := "/vagrant/build/go-repo-utils"
+	args := []string{"root", "-j", "-p", path}
+	out := ExecSuccessCommand(t, cmd, "/home", args)
+	expectedOut := `{"root":"` + path + `","vcs":"` + vcs + `"}`
+	if out != expectedOut {
+		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
+	}
+}
+
+func DoTestRootFromSubDir(path string, t Errorer) {
+	cmd := "/vagrant/build/go-repo-utils"
+	args := []string{"root"}
+	out := ExecSuccessCommand(t, cmd, path+"/sub/dir", args)
+	expectedOut := path + "\n"
+	if out != expectedOut {
+		t.Errorf("Expected out=%q, got out=%q\n", expectedOut, out)
+	}
+}
+
 func mustFileExists(t Errorer, p string) bool {
 	if _, err := os.Stat(p); os.IsNotExist(err) {
 		t.Errorf("file mut exists %q", p)
@@ -0,0 +1,536 @@
+// Package pijul implements go-repo-utils interfaces.
+//
+// pijul has no linear revision numbers, the Revision of a commit is its change hash.
+// pijul tags are unnamed states, the first line of a tag message is used as its name.
+// As changes are not ordered, commits between two tags are selected by their dates.
+package pijul
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+	"github.com/mh-cbon/verbose"
+)
+
+var logger = verbose.Auto()
+
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for pijul.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a pijul driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("pijul"))
+	if err != nil {
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
+	}
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
+	return cmd, nil
+}
+
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs pijul and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps pijul failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "No Pijul repository"),
+		strings.Contains(stderr, "not in a repository"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "not found"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
+// IsIt Test if given path is managed by pijul with pijul channel
+func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Test if given path is managed by pijul with pijul channel,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
+	args := []string{"channel"}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir holds a .pijul directory.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
+
+// IsRoot tells if dir holds a .pijul directory.
+func (d Driver) IsRoot(dir string) bool {
+	s, err := os.Stat(filepath.Join(dir, ".pijul"))
+	return err == nil && s.IsDir()
+}
+
+// tags returns the tags of path, their Revision is the state hash,
+// their Message is the tag name.
+func (d Driver) tags(ctx context.Context, path string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+
+	args := []string{"tag"}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return ret, err
+	}
+
+	for _, c := range ParsePijulLog(string(out)) {
+		c.Message = strings.Split(c.Message, "\n")[0]
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+// List tags on given path
+func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists tags on given path with pijul tag
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
+	tags := make([]string, 0)
+
+	states, err := d.tags(ctx, path)
+	if err != nil {
+		return tags, err
+	}
+
+	for _, s := range states {
+		if len(s.Message) > 0 {
+			tags = append(tags, s.Message)
+		}
+	}
+	return tags, nil
+}
+
+// ListTagsDetailed lists the tags on given path with the states they point to.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
+
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path with pijul tag,
+// pijul tags are annotated by their author, date and message.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	ret := make([]driver.Tag, 0)
+
+	out, err := d.run(ctx, path, []string{"tag"})
+	if err != nil {
+		return ret, err
+	}
+
+	for _, c := range ParsePijulLog(string(out)) {
+		if c.Subject == "" {
+			continue
+		}
+		ret = append(ret, driver.Tag{
+			Name:        c.Subject,
+			Revision:    c.Revision,
+			Date:        c.Time,
+			Tagger:      c.Author,
+			TaggerEmail: c.Email,
+			TaggerTime:  c.Time,
+			Message:     c.Body,
+			Annotated:   true,
+		})
+	}
+	return ret, nil
+}
+
+// IsClean Check uncommited files with pijul diff --short
+func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
+
+// IsCleanContext Check uncommited files with pijul diff --short
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"diff", "--short"}
+	out, err := d.run(ctx, path, args)
+	if err != nil {
+		return false, err
+	}
+
+	return len(strings.TrimSpace(string(out))) == 0, nil
+}
+
+// CreateTag Create given tag on path, the message is appended to the tag name
+func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
+
+// CreateTagContext Create given tag on path, the message is appended to the tag name
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+
+	tags, err := d.ListContext(ctx, path)
+	if err != nil {
+		return false, "", err
+	}
+
+	if contains(tags, tag) {
+		return false, "", fmt.Errorf("%w: %s", driver.ErrTagExists, tag)
+	}
+
+	if len(message) > 0 {
+		message = tag + "\n\n" + message
+	} else {
+		message = tag
+	}
+	args := []string{"tag", "create", "-m", message}
+	out, err := d.run(ctx, path, args)
+	return err == nil, string(out), err
+}
+
+// Add given file to pijul on path
+func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to pijul on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
+
+	args := []string{"add"}
+	if len(file) > 0 {
+		args = append(args, []string{file}...)
+	}
+	_, err := d.run(ctx, path, args)
+	return err
+}
+
+// Commit given files with message on path
+func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext records given files with message on path
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
+
+	if len(message) == 0 {
+		return errors.New("Message is required")
+	}
+
+	args := []string{"record", "--all", "-m", message}
+	if len(files) > 0 {
+		args = append(args, files...)
+	}
+	_, err := d.run(ctx, path, args)
+	return err
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
+
+// tagDate returns the date of given tag.
+func (d Driver) tagDate(ctx context.Context, path string, tag string) (*time.Time, error) {
+	tags, err := d.tags(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		if t.Message == tag {
+			if date := t.GetDate(); date != nil {
+				return date, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", driver.ErrUnknownRevision, tag)
+}
+
+// ListCommitsBetween List commits between two tags.
+// pijul changes are not ordered: the Revision of a commit is its change hash,
+// its Parents are empty, and since and to must be tag names,
+// the commits are those recorded after the date of the since tag and not after the date of the to tag.
+func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two tags,
+// it selects the changes recorded after since and before to.
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while pijul log prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points, while pijul log prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
+
+// WalkCommits calls fn with each commit selected by opts, while pijul log prints them,
+// their Revision is the change hash, their Parents are empty and they are selected
+// between the tags by date, see ListCommitsBetween.
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while pijul log prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
+	var sinceDate, toDate *time.Time
+	var err error
+	if since != "" {
+		if sinceDate, err = d.tagDate(ctx, path, since); err != nil {
+			return err
+		}
+	}
+	if to != "" && to != "HEAD" {
+		if toDate, err = d.tagDate(ctx, path, to); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"log"}
+	filter := opts.CommitFilter
+	if len(filter.Paths) > 0 {
+		args = append(args, "--")
+		args = append(args, filter.Paths...)
+		filter.Paths = nil
+	}
+	if fn, err = filter.Filter(fn); err != nil {
+		return err
+	}
+
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkPijulLog(r, func(c commit.Commit) error {
+			date := c.GetDate()
+			if date != nil && sinceDate != nil && date.After(*sinceDate) == false {
+				return nil
+			}
+			if date != nil && toDate != nil && date.After(*toDate) {
+				return nil
+			}
+			return fn(c)
+		})
+	})
+	return driver.Stopped(err)
+}
+
+// ParsePijulLog parses pijul log and pijul tag outputs to a list of commits
+func ParsePijulLog(log string) []commit.Commit {
+	ret := make([]commit.Commit, 0)
+	walkPijulLog(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret
+}
+
+// walkPijulLog calls fn with each commit of r, see ParsePijulLog.
+// The headers of a change start the lines, the lines of its message are indented,
+// so a message line such as "Change foo" does not start a change.
+func walkPijulLog(r io.Reader, fn driver.WalkFunc) error {
+	commitRe := regexp.MustCompile(`^(Change|State)\s+([^\s]+)\s*$`)
+	authorRe := regexp.MustCompile(`^Author:\s*(.+)$`)
+	emailRe := regexp.MustCompile(`^([^<]*)<([^>]+)>$`)
+	dateRe := regexp.MustCompile(`^Date:\s*(.+)$`)
+	var c *commit.Commit
+	var message []string
+	end := func() error {
+		if c == nil {
+			return nil
+		}
+		c.Message = strings.TrimSpace(strings.Join(message, "\n"))
+		c.Complete()
+		return fn(*c)
+	}
+	err := driver.Lines(r, func(line string) error {
+		line = strings.TrimRight(line, "\r")
+		if commitRe.MatchString(line) {
+			if err := end(); err != nil {
+				return err
+			}
+			c = &commit.Commit{}
+			message = []string{}
+			res := commitRe.FindStringSubmatch(line)
+			c.Revision = res[2]
+		} else if c == nil {
+			return nil
+		} else if strings.TrimSpace(line) == "" {
+			message = append(message, "")
+		} else if line[0] == ' ' || line[0] == '\t' {
+			message = append(message, trimIndent(line))
+		} else if authorRe.MatchString(line) {
+			res := authorRe.FindStringSubmatch(line)
+			c.Author = strings.TrimSpace(res[1])
+			if emailRe.MatchString(c.Author) {
+				res = emailRe.FindStringSubmatch(c.Author)
+				c.Author = strings.TrimSpace(res[1])
+				c.Email = res[2]
+			}
+		} else if dateRe.MatchString(line) {
+			res := dateRe.FindStringSubmatch(line)
+			c.Date = strings.TrimSpace(res[1])
+		}
+		return nil
+	})
+	if err == nil {
+		err = end()
+	}
+	return err
+}
+
+// trimIndent removes the indentation pijul prints before the lines of a message,
+// a tab or up to 4 spaces, the further indentation belongs to the message.
+func trimIndent(line string) string {
+	if line[0] == '\t' {
+		return line[1:]
+	}
+	for i := 0; i < 4 && len(line) > 0 && line[0] == ' '; i++ {
+		line = line[1:]
+	}
+	return line
+}
+
+// GetFirstRevision returns the hash of the first change of the repository
+func GetFirstRevision(path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
+
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
+
+// GetFirstRevisionContext returns the hash of the first change of the repository
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+
+	args := []string{"log", "--hash-only"}
+	out, err := d.run(ctx, path, args)
+
+	revs := make([]string, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			revs = append(revs, line)
+		}
+	}
+	if len(revs) == 0 {
+		return "", err
+	}
+	return revs[len(revs)-1], err
+}
@@ -0,0 +1,102 @@
+package pijul
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestParsePijulLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      string
+		expected []commit.Commit
+	}{
+		{
+			name: "pijul log",
+			log: `Change MQSZQ2IVQ5N4Y5VU7ZXCGBMAQNHG6BZEYUJSTTNOL6TGMDX5K7NQC
+Author: John Doe <john@doe.com>
+Date: 2017-01-02 10:00:00.123456789 UTC
+
+    feat: tomate 1.0.0
+
+    The long message,
+      with an indented line.
+    Change foo
+
+    Signed-off-by: John Doe <john@doe.com>
+
+Change 5IIRHQ6LFHVODSBYZDBR7ADNFM2SJBRIS6JOAMRIOTYEQDVCQ7GQC
+Author: jane
+Date: 2017-01-01 10:00:00 UTC
+
+    tomate notsemvertag
+`,
+			expected: []commit.Commit{
+				{
+					Revision: "MQSZQ2IVQ5N4Y5VU7ZXCGBMAQNHG6BZEYUJSTTNOL6TGMDX5K7NQC",
+					Author:   "John Doe",
+					Email:    "john@doe.com",
+					Message:  "feat: tomate 1.0.0\n\nThe long message,\n  with an indented line.\nChange foo\n\nSigned-off-by: John Doe <john@doe.com>",
+					Subject:  "feat: tomate 1.0.0",
+					Body:     "The long message,\n  with an indented line.\nChange foo\n\nSigned-off-by: John Doe <john@doe.com>",
+					Trailers: []commit.Trailer{{Key: "Signed-off-by", Value: "John Doe <john@doe.com>"}},
+				},
+				{
+					Revision: "5IIRHQ6LFHVODSBYZDBR7ADNFM2SJBRIS6JOAMRIOTYEQDVCQ7GQC",
+					Author:   "jane",
+					Message:  "tomate notsemvertag",
+					Subject:  "tomate notsemvertag",
+				},
+			},
+		},
+		{
+			name: "pijul tag",
+			log: `State SGLFT3VX2ON6XBT3YJTOUJPOS3EKQGQG4WMPTWW5B3ZX3SW3QCZAC
+Author: John Doe <john@doe.com>
+Date: 2017-01-02 10:01:00 UTC
+
+    v1.0.0
+
+    second release
+`,
+			expected: []commit.Commit{
+				{
+					Revision: "SGLFT3VX2ON6XBT3YJTOUJPOS3EKQGQG4WMPTWW5B3ZX3SW3QCZAC",
+					Author:   "John Doe",
+					Email:    "john@doe.com",
+					Message:  "v1.0.0\n\nsecond release",
+					Subject:  "v1.0.0",
+					Body:     "second release",
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		commits := ParsePijulLog(test.log)
+		if len(commits) != len(test.expected) {
+			t.Errorf("%s: expected %d commits, got %d", test.name, len(test.expected), len(commits))
+			continue
+		}
+		for i, c := range commits {
+			e := test.expected[i]
+			if c.Revision != e.Revision || c.Author != e.Author || c.Email != e.Email {
+				t.Errorf("%s: unexpected commit %+v", test.name, c)
+			}
+			if c.Message != e.Message || c.Subject != e.Subject || c.Body != e.Body {
+				t.Errorf("%s: unexpected message %q, subject %q, body %q", test.name, c.Message, c.Subject, c.Body)
+			}
+			if reflect.DeepEqual(c.Trailers, e.Trailers) == false {
+				t.Errorf("%s: expected trailers %+v, got %+v", test.name, e.Trailers, c.Trailers)
+			}
+			// pijul changes are not ordered, see ListCommitsBetween
+			if len(c.Parents) > 0 || c.Merge {
+				t.Errorf("%s: expected no parents, got %q", test.name, c.Parents)
+			}
+			if c.GetDate() == nil {
+				t.Errorf("%s: expected a date, got %q", test.name, c.Date)
+			}
+		}
+	}
+}
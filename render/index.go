@@ -0,0 +1,311 @@
+// Package render prints the results of the commands as text, json, jsonl, yaml or csv.
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Formats are the supported output formats.
+var Formats = []string{"text", "json", "jsonl", "yaml", "csv"}
+
+// Result describes the items printed by a command.
+type Result struct {
+	// List tells if the result is a list of items, it is a single item otherwise.
+	List bool
+	// Column names the csv column of the items which are not structs.
+	Column string
+	// Text prints an item in the text format.
+	Text func(w io.Writer, v interface{}) error
+}
+
+// Printer prints the items of a result in a format,
+// json and yaml print the whole result on Close, the other formats print the items as they are given.
+type Printer struct {
+	w       io.Writer
+	format  string
+	result  Result
+	items   []interface{}
+	csv     *csv.Writer
+	columns []string
+}
+
+// New returns a Printer of r to w in format, one of Formats.
+func New(w io.Writer, format string, r Result) (*Printer, error) {
+	for _, f := range Formats {
+		if f == format {
+			return &Printer{w: w, format: format, result: r, items: []interface{}{}}, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// Print prints v, an item of the result.
+func (p *Printer) Print(v interface{}) error {
+	switch p.format {
+	case "text":
+		return p.result.Text(p.w, v)
+	case "jsonl":
+		return json.NewEncoder(p.w).Encode(v)
+	case "csv":
+		return p.printCSV(v)
+	}
+	p.items = append(p.items, v)
+	return nil
+}
+
+// Close prints the result when its format needs all the items.
+func (p *Printer) Close() error {
+	var v interface{} = p.items
+	if p.result.List == false {
+		if len(p.items) == 0 {
+			return nil
+		}
+		v = p.items[0]
+	}
+	switch p.format {
+	case "json":
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(b)
+		return err
+	case "yaml":
+		b, err := YAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(b)
+		return err
+	case "csv":
+		if p.csv != nil {
+			p.csv.Flush()
+			return p.csv.Error()
+		}
+	}
+	return nil
+}
+
+func (p *Printer) printCSV(v interface{}) error {
+	fields, err := jsonFields(v)
+	if err != nil {
+		return err
+	}
+	if p.csv == nil {
+		p.csv = csv.NewWriter(p.w)
+		p.columns = Columns(v)
+		if p.columns == nil {
+			p.columns = []string{p.result.Column}
+		}
+		if err := p.csv.Write(p.columns); err != nil {
+			return err
+		}
+	}
+	row := make([]string, 0, len(p.columns))
+	if raw, ok := fields[""]; ok {
+		row = append(row, cell(raw, ok))
+	} else {
+		for _, c := range p.columns {
+			raw, ok := fields[c]
+			row = append(row, cell(raw, ok))
+		}
+	}
+	if err := p.csv.Write(row); err != nil {
+		return err
+	}
+	p.csv.Flush()
+	return p.csv.Error()
+}
+
+// Columns returns the json names of the fields of v, nil when it is not a struct.
+// The fields of the embedded structs are inlined, as encoding/json does.
+func Columns(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return columns(t)
+}
+
+func columns(t reflect.Type) []string {
+	ret := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			ret = append(ret, columns(f.Type)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		ret = append(ret, name)
+	}
+	return ret
+}
+
+// jsonFields returns the json encoded fields of v, or v under the "" key when it is not an object.
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if len(b) > 0 && b[0] == '{' {
+		err = json.Unmarshal(b, &fields)
+		return fields, err
+	}
+	fields[""] = b
+	return fields, nil
+}
+
+// cell returns the csv value of a json value, strings are unquoted,
+// arrays and objects are kept as json.
+func cell(raw json.RawMessage, ok bool) string {
+	if ok == false || string(raw) == "null" {
+		return ""
+	}
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+	}
+	return string(raw)
+}
+
+// YAML returns v encoded as yaml, the fields are named and ordered as encoding/json does.
+func YAML(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	n, err := readNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if n.block() == false {
+		buf.WriteString(n.scalar + "\n")
+	}
+	for _, line := range n.lines() {
+		buf.WriteString(line + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// node is a json value, keeping the order of the object keys.
+type node struct {
+	scalar string
+	object bool
+	keys   []string
+	values []node
+}
+
+func (n node) block() bool {
+	return n.scalar == "" && len(n.values) > 0
+}
+
+func readNode(dec *json.Decoder) (node, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return node{}, err
+	}
+	switch v := t.(type) {
+	case json.Delim:
+		n := node{object: v == '{'}
+		for dec.More() {
+			if n.object {
+				k, err := dec.Token()
+				if err != nil {
+					return n, err
+				}
+				n.keys = append(n.keys, k.(string))
+			}
+			child, err := readNode(dec)
+			if err != nil {
+				return n, err
+			}
+			n.values = append(n.values, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return n, err
+		}
+		if len(n.values) == 0 {
+			n.scalar = "[]"
+			if n.object {
+				n.scalar = "{}"
+			}
+		}
+		return n, nil
+	case string:
+		return node{scalar: strconv.Quote(v)}, nil
+	case json.Number:
+		return node{scalar: v.String()}, nil
+	case bool:
+		return node{scalar: strconv.FormatBool(v)}, nil
+	}
+	return node{scalar: "null"}, nil
+}
+
+// lines returns the yaml lines of a block node, without indentation.
+func (n node) lines() []string {
+	ret := []string{}
+	for i, child := range n.values {
+		prefix := "- "
+		if n.object {
+			prefix = quoteKey(n.keys[i]) + ":"
+			if child.block() == false {
+				ret = append(ret, prefix+" "+child.scalar)
+				continue
+			}
+			ret = append(ret, prefix)
+			for _, line := range child.lines() {
+				ret = append(ret, "  "+line)
+			}
+			continue
+		}
+		if child.block() == false {
+			ret = append(ret, prefix+child.scalar)
+			continue
+		}
+		for j, line := range child.lines() {
+			if j > 0 {
+				prefix = "  "
+			}
+			ret = append(ret, prefix+line)
+		}
+	}
+	return ret
+}
+
+// quoteKey quotes the keys which are not made of letters, digits, -, _ and . only.
+func quoteKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.' {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
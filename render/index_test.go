@@ -0,0 +1,89 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type inner struct {
+	Name string `json:"name"`
+}
+
+type item struct {
+	inner
+	Count  int               `json:"count"`
+	Tags   []string          `json:"tags,omitempty"`
+	Extra  map[string]string `json:"extra,omitempty"`
+	hidden string
+}
+
+func printAll(t *testing.T, format string, r Result, items ...interface{}) string {
+	var b bytes.Buffer
+	p, err := New(&b, format, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range items {
+		if err := p.Print(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestPrinter(t *testing.T) {
+	list := Result{List: true, Column: "tag", Text: func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprintln(w, v)
+		return err
+	}}
+	items := []interface{}{
+		item{inner: inner{Name: "a"}, Count: 1, Tags: []string{"x", "y"}},
+		item{inner: inner{Name: "b, \"c\""}, Extra: map[string]string{"k": "v"}},
+	}
+	outputs := map[string]string{
+		"text":  "{{a} 1 [x y] map[] }\n{{b, \"c\"} 0 [] map[k:v] }\n",
+		"json":  `[{"name":"a","count":1,"tags":["x","y"]},{"name":"b, \"c\"","count":0,"extra":{"k":"v"}}]`,
+		"jsonl": "{\"name\":\"a\",\"count\":1,\"tags\":[\"x\",\"y\"]}\n{\"name\":\"b, \\\"c\\\"\",\"count\":0,\"extra\":{\"k\":\"v\"}}\n",
+		"yaml":  "- name: \"a\"\n  count: 1\n  tags:\n    - \"x\"\n    - \"y\"\n- name: \"b, \\\"c\\\"\"\n  count: 0\n  extra:\n    k: \"v\"\n",
+		"csv":   "name,count,tags,extra\na,1,\"[\"\"x\"\",\"\"y\"\"]\",\n\"b, \"\"c\"\"\",0,,\"{\"\"k\"\":\"\"v\"\"}\"\n",
+	}
+	for format, expected := range outputs {
+		if got := printAll(t, format, list, items...); got != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, got)
+		}
+	}
+
+	scalars := map[string]string{
+		"json": `["1.0.0","1.0.1"]`,
+		"yaml": "- \"1.0.0\"\n- \"1.0.1\"\n",
+		"csv":  "tag\n1.0.0\n1.0.1\n",
+	}
+	for format, expected := range scalars {
+		if got := printAll(t, format, list, "1.0.0", "1.0.1"); got != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, got)
+		}
+	}
+
+	single := map[string]string{
+		"json": "true",
+		"yaml": "true\n",
+		"csv":  "clean\ntrue\n",
+	}
+	for format, expected := range single {
+		if got := printAll(t, format, Result{Column: "clean"}, true); got != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, got)
+		}
+	}
+
+	if got := printAll(t, "json", list); got != "[]" {
+		t.Errorf("Expected an empty list, got %q", got)
+	}
+	if _, err := New(nil, "xml", list); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
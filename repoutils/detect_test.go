@@ -0,0 +1,42 @@
+package repoutils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func gitRepo(b *testing.B) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		b.Skip("git is not installed")
+	}
+	dir := b.TempDir()
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		b.Fatalf("git init: %s %s", err, out)
+	}
+	sub := filepath.Join(dir, "sub", "dir")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		b.Fatal(err)
+	}
+	return sub
+}
+
+func benchmarkWhichVcs(b *testing.B, detection Detection) {
+	path := gitRepo(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vcs, err := WhichVcs(path, WithDetection(detection))
+		if err != nil || vcs != "git" {
+			b.Fatalf("Expected git, got vcs=%q err=%v", vcs, err)
+		}
+	}
+}
+
+func BenchmarkWhichVcsProbe(b *testing.B) {
+	benchmarkWhichVcs(b, DetectProbe)
+}
+
+func BenchmarkWhichVcsFilesystem(b *testing.B) {
+	benchmarkWhichVcs(b, DetectFilesystem)
+}
@@ -2,181 +2,143 @@
 package repoutils
 
 import (
-	"errors"
+	"context"
 	"sort"
 
-	"github.com/Masterminds/semver"
-	"github.com/mh-cbon/go-repo-utils/bzr"
 	"github.com/mh-cbon/go-repo-utils/commit"
-	"github.com/mh-cbon/go-repo-utils/git"
-	"github.com/mh-cbon/go-repo-utils/hg"
-	"github.com/mh-cbon/go-repo-utils/svn"
 )
 
-// Func type declarations
-type IsIt func(path string) bool
-type ListIt func(path string) ([]string, error)
-type IsItClean func(path string) (bool, error)
-type DoCreateTag func(path string, tag string, message string) (bool, string, error)
-type DoAdd func(path string, file string) error
-type DoCommit func(path string, message string, files []string) error
-type DoListCommitsBetween func(path string, since string, to string) ([]commit.Commit, error)
-type DoGetFirstRevision func(path string) (string, error)
-
-type isVcsResult struct {
-	name  string
-	found bool
-}
-
-// WhichVcs Determine the kind of VCS of given path
-func WhichVcs(path string) (string, error) {
-	fns := map[string]IsIt{
-		"git": git.IsIt,
-		"bzr": bzr.IsIt,
-		"hg":  hg.IsIt,
-		"svn": svn.IsIt,
-	}
-	vcsTests := map[string]bool{}
-
-	out := make(chan isVcsResult, len(fns))
-	for vcs, isIt := range fns {
-		go func(vcs string, isIt IsIt) {
-			out <- isVcsResult{name: vcs, found: isIt(path)}
-		}(vcs, isIt)
-	}
-	for res := range out {
-		vcsTests[res.name] = res.found
-		if len(vcsTests) == len(fns) {
-			close(out)
-		}
-	}
-
-	vcsFound := ""
-	howMuchFound := 0
-	for vcs, found := range vcsTests {
-		if found {
-			howMuchFound++
-			vcsFound += vcs
-		}
+func sortedNames(drivers map[string]Vcs) []string {
+	names := make([]string, 0, len(drivers))
+	for vcs := range drivers {
+		names = append(names, vcs)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	if howMuchFound == 0 {
-		return "", errors.New("No vcs project found at '" + path + "'")
-	}
+// List tags on given path according to given vcs
+func List(vcs string, path string) ([]string, error) {
+	return ListContext(context.Background(), vcs, path)
+}
 
-	if howMuchFound > 1 {
-		return "", errors.New("Multiple vcs project found at '" + path + "'. ?? => '" + vcsFound + "'")
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, vcs string, path string) ([]string, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return make([]string, 0), err
 	}
+	return driver.ListContext(ctx, path)
+}
 
-	return vcsFound, nil
+// ListTagsDetailed lists the tags on given path according to given vcs,
+// with their revision, date, tagger and annotation.
+func ListTagsDetailed(vcs string, path string) ([]Tag, error) {
+	return ListTagsDetailedContext(context.Background(), vcs, path)
 }
 
-// List tags on given path according to given vcs
-func List(vcs string, path string) ([]string, error) {
-	fns := map[string]ListIt{
-		"git": git.List,
-		"bzr": bzr.List,
-		"hg":  hg.List,
-		"svn": svn.List,
-	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return make([]string, 0), errors.New("Unknown VCS '" + vcs + "'")
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, vcs string, path string) ([]Tag, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return make([]Tag, 0), err
 	}
-	return fn(path)
+	return listTagsDetailed(ctx, driver, path)
 }
 
 // IsClean Ensure given path does not contain uncommited files
 func IsClean(vcs string, path string) (bool, error) {
-	fns := map[string]IsItClean{
-		"git": git.IsClean,
-		"bzr": bzr.IsClean,
-		"hg":  hg.IsClean,
-		"svn": svn.IsClean,
-	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return false, errors.New("Unknown VCS '" + vcs + "'")
+	return IsCleanContext(context.Background(), vcs, path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, vcs string, path string) (bool, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return false, err
 	}
-	return fn(path)
+	return driver.IsCleanContext(ctx, path)
 }
 
 // CreateTag Create tag on given path
 func CreateTag(vcs string, path string, tag string, message string) (bool, string, error) {
-	fns := map[string]DoCreateTag{
-		"git": git.CreateTag,
-		"bzr": bzr.CreateTag,
-		"hg":  hg.CreateTag,
-		"svn": svn.CreateTag,
-	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return false, "", errors.New("Unknown VCS '" + vcs + "'")
+	return CreateTagContext(context.Background(), vcs, path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, vcs string, path string, tag string, message string) (bool, string, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return false, "", err
 	}
-	return fn(path, tag, message)
+	return driver.CreateTagContext(ctx, path, tag, message)
 }
 
 // Add a file
 func Add(vcs string, path string, file string) error {
-	fns := map[string]DoAdd{
-		"git": git.Add,
-		"bzr": bzr.Add,
-		"hg":  hg.Add,
-		"svn": svn.Add,
-	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return errors.New("Unknown VCS '" + vcs + "'")
+	return AddContext(context.Background(), vcs, path, file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, vcs string, path string, file string) error {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return err
 	}
-	return fn(path, file)
+	return driver.AddContext(ctx, path, file)
 }
 
 // Commit files on path with message
 func Commit(vcs string, path string, message string, files []string) error {
-	fns := map[string]DoCommit{
-		"git": git.Commit,
-		"bzr": bzr.Commit,
-		"hg":  hg.Commit,
-		"svn": svn.Commit,
-	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return errors.New("Unknown VCS '" + vcs + "'")
+	return CommitContext(context.Background(), vcs, path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, vcs string, path string, message string, files []string) error {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return err
 	}
-	return fn(path, message, files)
+	return driver.CommitContext(ctx, path, message, files)
 }
 
 // FilterSemverTags Filter out invalid semver tags
 func FilterSemverTags(dirtyTags []string) []string {
-	tags := make([]string, 0)
-	for _, tag := range dirtyTags {
-		_, err := semver.NewVersion(tag)
-		if err == nil {
-			tags = append(tags, tag)
-		}
-	}
-	return tags
+	return TagPrefix("").FilterSemverTags(dirtyTags)
 }
 
-// SortSemverTags Sorts given list of semver tags, invalid semver tags are appended to the end
+// SemverTags returns the valid semver tags of given list in ascending semver order,
+// tags of equal versions, such as v1.0.0 and 1.0.0, keep their order.
+func SemverTags(tags []string) []SemverTag {
+	return TagPrefix("").SemverTags(tags)
+}
+
+// SortSemverTags Sorts given list of semver tags, invalid semver tags are appended to the end.
+// The tags keep their spelling, see SemverTags.
 func SortSemverTags(unsortedTags []string) []string {
-	dirtyTags := make([]string, 0)
-	vs := make([]*semver.Version, 0)
-	for _, r := range unsortedTags {
-		v, err := semver.NewVersion(r)
-		if err != nil {
-			dirtyTags = append(dirtyTags, r)
-		} else {
-			vs = append(vs, v)
-		}
-	}
-	sort.Sort(semver.Collection(vs))
-	sortedTags := make([]string, 0)
-	for _, t := range vs {
-		sortedTags = append(sortedTags, t.String())
-	}
-	sortedTags = append(sortedTags, dirtyTags...)
-	return sortedTags
+	return TagPrefix("").SortSemverTags(unsortedTags)
+}
+
+// FilterTagsByConstraint keeps the semver tags of given list matching constraint,
+// such as ">=1.2, <2.0", see github.com/Masterminds/semver.
+// As for the constraints, the prerelease tags match only constraints with a prerelease.
+func FilterTagsByConstraint(dirtyTags []string, constraint string) ([]string, error) {
+	return TagPrefix("").FilterTagsByConstraint(dirtyTags, constraint)
+}
+
+// FilterStableTags keeps the semver tags of given list which are not prereleases.
+func FilterStableTags(dirtyTags []string) []string {
+	return TagPrefix("").FilterStableTags(dirtyTags)
+}
+
+// FilterTagsByMajor keeps the semver tags of given list whose major version is major.
+func FilterTagsByMajor(dirtyTags []string, major int64) []string {
+	return TagPrefix("").FilterTagsByMajor(dirtyTags, major)
+}
+
+// LatestSemverTag returns the greatest semver tag of given list, an empty string when there is none.
+func LatestSemverTag(tags []string) string {
+	return TagPrefix("").LatestSemverTag(tags)
 }
 
 // ReverseTags Reverse given list of tags
@@ -189,32 +151,62 @@ func ReverseTags(tags []string) []string {
 
 // ListCommitsBetween Lists commits between given tag
 func ListCommitsBetween(vcs string, path string, since string, to string) ([]commit.Commit, error) {
-	ret := make([]commit.Commit, 0)
-	fns := map[string]DoListCommitsBetween{
-		"git": git.ListCommitsBetween,
-		"bzr": bzr.ListCommitsBetween,
-		"hg":  hg.ListCommitsBetween,
-		"svn": svn.ListCommitsBetween,
+	return ListCommitsBetweenContext(context.Background(), vcs, path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, vcs string, path string, since string, to string) ([]commit.Commit, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return make([]commit.Commit, 0), err
 	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return ret, errors.New("Unknown VCS '" + vcs + "'")
+	return driver.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// WalkCommitsBetween calls fn with each commit between given tags, while the vcs prints them,
+// fn returns ErrStop to end the walk early.
+func WalkCommitsBetween(vcs string, path string, since string, to string, fn WalkFunc) error {
+	return WalkCommitsBetweenContext(context.Background(), vcs, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, vcs string, path string, since string, to string, fn WalkFunc) error {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return err
 	}
-	return fn(path, since, to)
+	return walkCommitsBetween(ctx, driver, path, since, to, fn)
+}
+
+// ListCommits lists the commits selected by opts.
+func ListCommits(vcs string, path string, opts ListOptions) ([]commit.Commit, error) {
+	return ListCommitsContext(context.Background(), vcs, path, opts)
+}
+
+// ListCommitsContext is like ListCommits, bounded by ctx.
+func ListCommitsContext(ctx context.Context, vcs string, path string, opts ListOptions) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return ret, err
+	}
+	err = walkCommits(ctx, driver, path, opts, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
 }
 
 // GetFirstRevision Returns the first revision of the repostiory.
 func GetFirstRevision(vcs string, path string) (string, error) {
-	ret := ""
-	fns := map[string]DoGetFirstRevision{
-		"git": git.GetFirstRevision,
-		"bzr": bzr.GetFirstRevision,
-		"hg":  hg.GetFirstRevision,
-		"svn": svn.GetFirstRevision,
-	}
-	fn, ok := fns[vcs]
-	if ok == false {
-		return ret, errors.New("Unknown VCS '" + vcs + "'")
+	return GetFirstRevisionContext(context.Background(), vcs, path)
+}
+
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, vcs string, path string) (string, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return "", err
 	}
-	return fn(path)
+	return driver.GetFirstRevisionContext(ctx, path)
 }
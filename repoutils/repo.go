@@ -0,0 +1,335 @@
+package repoutils
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
+	"github.com/mh-cbon/go-repo-utils/git"
+)
+
+// Option configures a Repo.
+type Option func(o *options)
+
+// Detection is the strategy used to detect the vcs of a path.
+type Detection int
+
+const (
+	// DetectProbe runs each vcs binary against the path.
+	DetectProbe Detection = iota
+	// DetectFilesystem looks up the vcs metadata directories on disk,
+	// it falls back to DetectProbe when none is found.
+	DetectFilesystem
+)
+
+type options struct {
+	vcs       string
+	detection Detection
+	prefer    []string
+	bins      map[string]string
+	overrides map[string]Vcs
+	config    driver.Config
+}
+
+func newOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// drivers returns the registered drivers configured with o,
+// restricted to the vcs given with WithVcs.
+func (o options) drivers() map[string]Vcs {
+	all := configuredDrivers(o)
+	if o.vcs == "" {
+		return all
+	}
+	ret := map[string]Vcs{}
+	if d, ok := all[o.vcs]; ok {
+		ret[o.vcs] = d
+	}
+	return ret
+}
+
+// WithVcs skips the detection and uses given vcs.
+func WithVcs(vcs string) Option {
+	return func(o *options) {
+		o.vcs = vcs
+	}
+}
+
+// WithDetection sets the strategy used to detect the vcs, default is DetectProbe.
+func WithDetection(detection Detection) Option {
+	return func(o *options) {
+		o.detection = detection
+	}
+}
+
+// WithPreference sets the vcs to pick, in order, when several of them
+// manage the path at the same depth.
+func WithPreference(vcs ...string) Option {
+	return func(o *options) {
+		o.prefer = append(o.prefer, vcs...)
+	}
+}
+
+// WithDriver uses given driver for vcs instead of the registered one.
+func WithDriver(vcs string, d Vcs) Option {
+	return func(o *options) {
+		if o.overrides == nil {
+			o.overrides = map[string]Vcs{}
+		}
+		o.overrides[vcs] = d
+	}
+}
+
+// WithNativeGit reads git repositories without the git binary,
+// it is still required to create tags and commits.
+func WithNativeGit() Option {
+	return WithDriver("git", git.NativeDriver{})
+}
+
+// WithBin overrides the binary used by given vcs.
+func WithBin(vcs string, bin string) Option {
+	return func(o *options) {
+		if o.bins == nil {
+			o.bins = map[string]string{}
+		}
+		o.bins[vcs] = bin
+	}
+}
+
+// WithEnv appends given variables to the environment of the vcs processes.
+func WithEnv(env ...string) Option {
+	return func(o *options) {
+		o.config.Env = append(o.config.Env, env...)
+	}
+}
+
+// WithTimeout bounds the duration of each vcs process.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.config.Timeout = timeout
+	}
+}
+
+// WithFiles lists the files changed by each commit.
+func WithFiles() Option {
+	return func(o *options) {
+		o.config.Files = true
+	}
+}
+
+// WithLogger sets the logger receiving the debug messages of the drivers.
+func WithLogger(logger driver.Logger) Option {
+	return func(o *options) {
+		o.config.Logger = logger
+	}
+}
+
+// configuredDrivers returns the registered drivers, configured with o when they support it.
+func configuredDrivers(o options) map[string]Vcs {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	ret := map[string]Vcs{}
+	for vcs, d := range drivers {
+		ret[vcs] = configure(vcs, d, o)
+	}
+	for vcs, d := range o.overrides {
+		ret[vcs] = configure(vcs, d, o)
+	}
+	return ret
+}
+
+func configure(vcs string, d Vcs, o options) Vcs {
+	c, ok := d.(Configurable)
+	if ok == false {
+		return d
+	}
+	config := o.config
+	config.Bin = o.bins[vcs]
+	return c.Configure(config)
+}
+
+// Repo is a repository bound to its vcs driver.
+type Repo struct {
+	vcs    string
+	path   string
+	root   string
+	driver Vcs
+}
+
+// Open detects the vcs of given path and returns a Repo bound to it.
+func Open(path string, opts ...Option) (*Repo, error) {
+	return OpenContext(context.Background(), path, opts...)
+}
+
+// OpenContext is like Open, bounded by ctx.
+func OpenContext(ctx context.Context, path string, opts ...Option) (*Repo, error) {
+	o := newOptions(opts)
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := whichVcs(ctx, path, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repo{vcs: c.Vcs, path: path, root: c.Root, driver: o.drivers()[c.Vcs]}, nil
+}
+
+// Vcs returns the name of the vcs of the repository.
+func (r *Repo) Vcs() string {
+	return r.vcs
+}
+
+// Path returns the absolute path of the repository.
+func (r *Repo) Path() string {
+	return r.path
+}
+
+// Root returns the root of the working copy,
+// it is the path when the driver does not implement RootFinder.
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// Driver returns the configured driver of the repository.
+func (r *Repo) Driver() Vcs {
+	return r.driver
+}
+
+// Tags lists the tags of the repository.
+func (r *Repo) Tags() ([]string, error) {
+	return r.TagsContext(context.Background())
+}
+
+// TagsContext is like Tags, bounded by ctx.
+func (r *Repo) TagsContext(ctx context.Context) ([]string, error) {
+	return r.driver.ListContext(ctx, r.path)
+}
+
+// TagsDetailed returns the tags of the repository with their revision, date, tagger and annotation.
+func (r *Repo) TagsDetailed() ([]Tag, error) {
+	return r.TagsDetailedContext(context.Background())
+}
+
+// TagsDetailedContext is like TagsDetailed, bounded by ctx.
+func (r *Repo) TagsDetailedContext(ctx context.Context) ([]Tag, error) {
+	return listTagsDetailed(ctx, r.driver, r.path)
+}
+
+// NextVersion returns the version following the latest semver tag of the repository,
+// given the commits since it, see NextVersionOf.
+func (r *Repo) NextVersion(opts NextVersionOptions) (VersionBump, error) {
+	return r.NextVersionContext(context.Background(), opts)
+}
+
+// NextVersionContext is like NextVersion, bounded by ctx.
+func (r *Repo) NextVersionContext(ctx context.Context, opts NextVersionOptions) (VersionBump, error) {
+	return nextVersion(ctx, r.driver, r.path, opts)
+}
+
+// IsClean tells if the repository does not contain uncommited files.
+func (r *Repo) IsClean() (bool, error) {
+	return r.IsCleanContext(context.Background())
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func (r *Repo) IsCleanContext(ctx context.Context) (bool, error) {
+	return r.driver.IsCleanContext(ctx, r.path)
+}
+
+// CreateTag creates a tag with given message.
+func (r *Repo) CreateTag(tag string, message string) (bool, string, error) {
+	return r.CreateTagContext(context.Background(), tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func (r *Repo) CreateTagContext(ctx context.Context, tag string, message string) (bool, string, error) {
+	return r.driver.CreateTagContext(ctx, r.path, tag, message)
+}
+
+// Add a file.
+func (r *Repo) Add(file string) error {
+	return r.AddContext(context.Background(), file)
+}
+
+// AddContext is like Add, bounded by ctx.
+func (r *Repo) AddContext(ctx context.Context, file string) error {
+	return r.driver.AddContext(ctx, r.path, file)
+}
+
+// Commit files with message.
+func (r *Repo) Commit(message string, files []string) error {
+	return r.CommitContext(context.Background(), message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func (r *Repo) CommitContext(ctx context.Context, message string, files []string) error {
+	return r.driver.CommitContext(ctx, r.path, message, files)
+}
+
+// Commits lists the commits between two points.
+func (r *Repo) Commits(since string, to string) ([]commit.Commit, error) {
+	return r.CommitsContext(context.Background(), since, to)
+}
+
+// CommitsContext is like Commits, bounded by ctx.
+func (r *Repo) CommitsContext(ctx context.Context, since string, to string) ([]commit.Commit, error) {
+	return r.driver.ListCommitsBetweenContext(ctx, r.path, since, to)
+}
+
+// WalkCommits calls fn with each commit between two points, while the vcs prints them,
+// fn returns ErrStop to end the walk early.
+func (r *Repo) WalkCommits(since string, to string, fn WalkFunc) error {
+	return r.WalkCommitsContext(context.Background(), since, to, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func (r *Repo) WalkCommitsContext(ctx context.Context, since string, to string, fn WalkFunc) error {
+	return walkCommitsBetween(ctx, r.driver, r.path, since, to, fn)
+}
+
+// ListCommits lists the commits selected by opts.
+func (r *Repo) ListCommits(opts ListOptions) ([]commit.Commit, error) {
+	return r.ListCommitsContext(context.Background(), opts)
+}
+
+// ListCommitsContext is like ListCommits, bounded by ctx.
+func (r *Repo) ListCommitsContext(ctx context.Context, opts ListOptions) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := r.WalkCommitsWithContext(ctx, opts, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsWith calls fn with each commit selected by opts, while the vcs prints them,
+// fn returns ErrStop to end the walk early.
+func (r *Repo) WalkCommitsWith(opts ListOptions, fn WalkFunc) error {
+	return r.WalkCommitsWithContext(context.Background(), opts, fn)
+}
+
+// WalkCommitsWithContext is like WalkCommitsWith, bounded by ctx.
+func (r *Repo) WalkCommitsWithContext(ctx context.Context, opts ListOptions, fn WalkFunc) error {
+	return walkCommits(ctx, r.driver, r.path, opts, fn)
+}
+
+// FirstRevision returns the first revision of the repository.
+func (r *Repo) FirstRevision() (string, error) {
+	return r.FirstRevisionContext(context.Background())
+}
+
+// FirstRevisionContext is like FirstRevision, bounded by ctx.
+func (r *Repo) FirstRevisionContext(ctx context.Context) (string, error) {
+	return r.driver.GetFirstRevisionContext(ctx, r.path)
+}
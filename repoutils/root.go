@@ -0,0 +1,172 @@
+package repoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Candidate is a vcs managing a path, with the root of its working copy.
+type Candidate struct {
+	Vcs  string `json:"vcs"`
+	Root string `json:"root"`
+}
+
+// AmbiguousError is returned when several vcs manage a path at the same depth,
+// and none of them is preferred.
+type AmbiguousError struct {
+	Path       string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	found := make([]string, 0, len(e.Candidates))
+	for _, c := range e.Candidates {
+		found = append(found, c.Vcs+" at '"+c.Root+"'")
+	}
+	return "Multiple vcs project found at '" + e.Path + "': " + strings.Join(found, ", ")
+}
+
+// FindRoot walks up from path to the nearest working copy root,
+// it returns the root and the vcs managing it.
+// Only the drivers implementing RootFinder are considered.
+func FindRoot(path string, opts ...Option) (string, string, error) {
+	o := newOptions(opts)
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	c, err := findRoot(path, o.drivers(), o.prefer)
+	return c.Root, c.Vcs, err
+}
+
+func findRoot(path string, drivers map[string]Vcs, prefer []string) (Candidate, error) {
+	names := sortedNames(drivers)
+	dir := path
+	for {
+		found := make([]Candidate, 0)
+		for _, vcs := range names {
+			if f, ok := drivers[vcs].(RootFinder); ok && f.IsRoot(dir) {
+				found = append(found, Candidate{Vcs: vcs, Root: dir})
+			}
+		}
+		if len(found) > 0 {
+			return resolve(path, found, prefer)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return Candidate{}, fmt.Errorf("No vcs project found at '%s': %w", path, ErrNotARepository)
+}
+
+// rootOf returns the root of the working copy of d containing path,
+// it is path when d is not a RootFinder or does not find it.
+func rootOf(vcs string, d Vcs, path string) string {
+	c, err := findRoot(path, map[string]Vcs{vcs: d}, nil)
+	if err != nil {
+		return path
+	}
+	return c.Root
+}
+
+// resolve picks the innermost candidate, ties are broken with prefer.
+// All the candidates roots must be path or one of its parents.
+func resolve(path string, candidates []Candidate, prefer []string) (Candidate, error) {
+	if len(candidates) == 0 {
+		return Candidate{}, fmt.Errorf("No vcs project found at '%s': %w", path, ErrNotARepository)
+	}
+	innermost := make([]Candidate, 0)
+	for _, c := range candidates {
+		if len(innermost) == 0 || len(c.Root) > len(innermost[0].Root) {
+			innermost = []Candidate{c}
+		} else if len(c.Root) == len(innermost[0].Root) {
+			innermost = append(innermost, c)
+		}
+	}
+	if len(innermost) == 1 {
+		return innermost[0], nil
+	}
+	for _, vcs := range prefer {
+		for _, c := range innermost {
+			if c.Vcs == vcs {
+				return c, nil
+			}
+		}
+	}
+	return Candidate{}, &AmbiguousError{Path: path, Candidates: candidates}
+}
+
+type isVcsResult struct {
+	name  string
+	found bool
+	err   error
+}
+
+// WhichVcs Determine the kind of VCS of given path.
+// When several vcs manage the path, the innermost working copy wins,
+// the preference given with WithPreference breaks the ties.
+func WhichVcs(path string, opts ...Option) (string, error) {
+	return WhichVcsContext(context.Background(), path, opts...)
+}
+
+// WhichVcsContext is like WhichVcs, bounded by ctx.
+func WhichVcsContext(ctx context.Context, path string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	c, err := whichVcs(ctx, path, o)
+	return c.Vcs, err
+}
+
+func whichVcs(ctx context.Context, path string, o options) (Candidate, error) {
+	drivers := o.drivers()
+	if o.vcs != "" {
+		d, ok := drivers[o.vcs]
+		if ok == false {
+			return Candidate{}, fmt.Errorf("%w '%s'", ErrUnknownVcs, o.vcs)
+		}
+		return Candidate{Vcs: o.vcs, Root: rootOf(o.vcs, d, path)}, nil
+	}
+
+	if o.detection == DetectFilesystem {
+		c, err := findRoot(path, drivers, o.prefer)
+		if errors.Is(err, ErrNotARepository) == false {
+			return c, err
+		}
+	}
+
+	vcsTests := map[string]bool{}
+	out := make(chan isVcsResult, len(drivers))
+	for vcs, driver := range drivers {
+		go func(vcs string, driver Vcs) {
+			found, err := driver.IsItContext(ctx, path)
+			out <- isVcsResult{name: vcs, found: found, err: err}
+		}(vcs, driver)
+	}
+	var probeErr error
+	for len(vcsTests) < len(drivers) {
+		res := <-out
+		vcsTests[res.name] = res.found
+		if res.err != nil {
+			probeErr = res.err
+		}
+	}
+	if probeErr != nil {
+		return Candidate{}, probeErr
+	}
+
+	candidates := make([]Candidate, 0)
+	for _, vcs := range sortedNames(drivers) {
+		if vcsTests[vcs] {
+			candidates = append(candidates, Candidate{Vcs: vcs, Root: rootOf(vcs, drivers[vcs], path)})
+		}
+	}
+	return resolve(path, candidates, o.prefer)
+}
@@ -0,0 +1,80 @@
+package repoutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	candidates := []Candidate{
+		{Vcs: "svn", Root: "/home/svn_work"},
+		{Vcs: "git", Root: "/home/svn_work/lib"},
+	}
+	c, err := resolve("/home/svn_work/lib/sub", candidates, nil)
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if c.Vcs != "git" {
+		t.Errorf("Expected vcs=%q, got vcs=%q\n", "git", c.Vcs)
+	}
+
+	candidates = []Candidate{
+		{Vcs: "git", Root: "/home/hggit"},
+		{Vcs: "hg", Root: "/home/hggit"},
+	}
+	_, err = resolve("/home/hggit", candidates, nil)
+	var ambiguous *AmbiguousError
+	if errors.As(err, &ambiguous) == false {
+		t.Errorf("Expected an AmbiguousError, got err=%v\n", err)
+	} else if len(ambiguous.Candidates) != 2 {
+		t.Errorf("Expected 2 candidates, got %d\n", len(ambiguous.Candidates))
+	}
+
+	c, err = resolve("/home/hggit", candidates, []string{"svn", "hg"})
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if c.Vcs != "hg" {
+		t.Errorf("Expected vcs=%q, got vcs=%q\n", "hg", c.Vcs)
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	dir := t.TempDir()
+	mustMkdir(t, filepath.Join(dir, ".svn"))
+	mustMkdir(t, filepath.Join(dir, "lib", ".git"))
+	mustMkdir(t, filepath.Join(dir, "lib", "sub"))
+	mustMkdir(t, filepath.Join(dir, "doc"))
+
+	root, vcs, err := FindRoot(filepath.Join(dir, "lib", "sub"))
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if root != filepath.Join(dir, "lib") || vcs != "git" {
+		t.Errorf("Expected git at %q, got %s at %q\n", filepath.Join(dir, "lib"), vcs, root)
+	}
+
+	root, vcs, err = FindRoot(filepath.Join(dir, "doc"))
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if root != dir || vcs != "svn" {
+		t.Errorf("Expected svn at %q, got %s at %q\n", dir, vcs, root)
+	}
+
+	root, vcs, err = FindRoot(filepath.Join(dir, "lib", "sub"), WithVcs("svn"))
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if root != dir || vcs != "svn" {
+		t.Errorf("Expected svn at %q, got %s at %q\n", dir, vcs, root)
+	}
+}
+
+func mustMkdir(t *testing.T, dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
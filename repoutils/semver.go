@@ -0,0 +1,157 @@
+package repoutils
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// SemverTag is a tag name with its parsed semver version.
+type SemverTag struct {
+	// Name is the tag as the vcs spells it, such as v1.2.0, 1.2 or api/v1.4.0.
+	Name string
+	// Prefix is the part of Name before the version, such as api/ in api/v1.4.0.
+	Prefix  string
+	Version *semver.Version
+}
+
+// String returns the name of the tag.
+func (t SemverTag) String() string {
+	return t.Name
+}
+
+// TagPrefix selects the namespaced semver tags, such as api/v1.4.0 for the prefix api/,
+// as Go modules in subdirectories are tagged.
+// It is a literal prefix, or a path.Match pattern, such as */ for any namespace,
+// the prefix is stripped before the semver parsing and the tags keep their full name.
+// The empty prefix selects the tags which are only a version.
+type TagPrefix string
+
+// IsPattern tells if p contains path.Match special characters.
+func (p TagPrefix) IsPattern() bool {
+	return strings.ContainsAny(string(p), `*?[\`)
+}
+
+// Parse returns the version of tag, the shortest prefix of tag matching p is stripped.
+func (p TagPrefix) Parse(tag string) (SemverTag, error) {
+	for i := 0; i <= len(tag); i++ {
+		ok, err := path.Match(string(p), tag[:i])
+		if err != nil {
+			return SemverTag{}, fmt.Errorf("invalid tag prefix %q: %w", string(p), err)
+		}
+		if ok == false {
+			continue
+		}
+		if v, err := semver.NewVersion(tag[i:]); err == nil {
+			return SemverTag{Name: tag, Prefix: tag[:i], Version: v}, nil
+		}
+	}
+	return SemverTag{}, fmt.Errorf("%q is not a semver tag with the prefix %q", tag, string(p))
+}
+
+// Tag returns tag with the prefix p, tag is returned as is when it already matches p.
+// The version of a pattern prefix can not be prefixed.
+func (p TagPrefix) Tag(tag string) (string, error) {
+	if _, err := p.Parse(tag); err == nil || p == "" {
+		return tag, nil
+	}
+	if p.IsPattern() {
+		return tag, fmt.Errorf("the tag %q does not match the tag prefix %q", tag, string(p))
+	}
+	return string(p) + tag, nil
+}
+
+// FilterSemverTags keeps the semver tags of given list with the prefix p.
+func (p TagPrefix) FilterSemverTags(dirtyTags []string) []string {
+	tags := make([]string, 0)
+	for _, t := range p.semverTagsOf(dirtyTags) {
+		tags = append(tags, t.Name)
+	}
+	return tags
+}
+
+// SemverTags returns the semver tags of given list with the prefix p in ascending semver order,
+// tags of equal versions keep their order.
+func (p TagPrefix) SemverTags(tags []string) []SemverTag {
+	ret := p.semverTagsOf(tags)
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Version.LessThan(ret[j].Version)
+	})
+	return ret
+}
+
+// semverTagsOf returns the semver tags of given list with the prefix p, in their order.
+func (p TagPrefix) semverTagsOf(tags []string) []SemverTag {
+	ret := make([]SemverTag, 0)
+	for _, tag := range tags {
+		if t, err := p.Parse(tag); err == nil {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// SortSemverTags sorts given list as SemverTags, the other tags are appended to the end.
+func (p TagPrefix) SortSemverTags(unsortedTags []string) []string {
+	sortedTags := make([]string, 0)
+	for _, t := range p.SemverTags(unsortedTags) {
+		sortedTags = append(sortedTags, t.Name)
+	}
+	for _, tag := range unsortedTags {
+		if _, err := p.Parse(tag); err != nil {
+			sortedTags = append(sortedTags, tag)
+		}
+	}
+	return sortedTags
+}
+
+// FilterTagsByConstraint keeps the semver tags of given list with the prefix p matching constraint,
+// see FilterTagsByConstraint.
+func (p TagPrefix) FilterTagsByConstraint(dirtyTags []string, constraint string) ([]string, error) {
+	tags := make([]string, 0)
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return tags, fmt.Errorf("invalid semver constraint %q: %w", constraint, err)
+	}
+	for _, t := range p.semverTagsOf(dirtyTags) {
+		if c.Check(t.Version) {
+			tags = append(tags, t.Name)
+		}
+	}
+	return tags, nil
+}
+
+// FilterStableTags keeps the semver tags of given list with the prefix p which are not prereleases.
+func (p TagPrefix) FilterStableTags(dirtyTags []string) []string {
+	tags := make([]string, 0)
+	for _, t := range p.semverTagsOf(dirtyTags) {
+		if t.Version.Prerelease() == "" {
+			tags = append(tags, t.Name)
+		}
+	}
+	return tags
+}
+
+// FilterTagsByMajor keeps the semver tags of given list with the prefix p whose major version is major.
+func (p TagPrefix) FilterTagsByMajor(dirtyTags []string, major int64) []string {
+	tags := make([]string, 0)
+	for _, t := range p.semverTagsOf(dirtyTags) {
+		if t.Version.Major() == major {
+			tags = append(tags, t.Name)
+		}
+	}
+	return tags
+}
+
+// LatestSemverTag returns the greatest semver tag of given list with the prefix p,
+// an empty string when there is none.
+func (p TagPrefix) LatestSemverTag(tags []string) string {
+	sorted := p.SemverTags(tags)
+	if len(sorted) == 0 {
+		return ""
+	}
+	return sorted[len(sorted)-1].Name
+}
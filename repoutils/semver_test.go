@@ -0,0 +1,108 @@
+package repoutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortSemverTags(t *testing.T) {
+	tags := []string{"v1.2.0", "nope", "1.0.0", "1.2", "v1.0.0", "0.9.1-beta"}
+	expected := []string{"0.9.1-beta", "1.0.0", "v1.0.0", "v1.2.0", "1.2", "nope"}
+	got := SortSemverTags(tags)
+	if reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+
+	semverTags := SemverTags(tags)
+	if len(semverTags) != 5 {
+		t.Fatalf("Expected 5 semver tags, got %d\n", len(semverTags))
+	}
+	last := semverTags[len(semverTags)-1]
+	if last.Name != "1.2" || last.Version.String() != "1.2.0" {
+		t.Errorf("Expected tag=1.2 version=1.2.0, got tag=%s version=%s\n", last.Name, last.Version)
+	}
+}
+
+func TestFilterTagsByConstraint(t *testing.T) {
+	tags := []string{"v1.1.0", "1.2.0", "v1.3.0-beta", "v1.4.1", "2.0.0", "nope"}
+	got, err := FilterTagsByConstraint(tags, ">=1.2, <2.0")
+	if err != nil {
+		t.Fatalf("Expected err=nil, got err=%s\n", err)
+	}
+	expected := []string{"1.2.0", "v1.4.1"}
+	if reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+	if _, err := FilterTagsByConstraint(tags, "not a constraint"); err == nil {
+		t.Errorf("Expected an error for an invalid constraint\n")
+	}
+
+	expected = []string{"v1.1.0", "1.2.0", "v1.4.1", "2.0.0"}
+	if got := FilterStableTags(tags); reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+
+	expected = []string{"v1.1.0", "1.2.0", "v1.3.0-beta", "v1.4.1"}
+	if got := FilterTagsByMajor(tags, 1); reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+
+	if got := LatestSemverTag(FilterStableTags(FilterTagsByMajor(tags, 1))); got != "v1.4.1" {
+		t.Errorf("Expected latest=%q, got latest=%q\n", "v1.4.1", got)
+	}
+	if got := LatestSemverTag([]string{"nope"}); got != "" {
+		t.Errorf("Expected latest=%q, got latest=%q\n", "", got)
+	}
+}
+
+func TestTagPrefix(t *testing.T) {
+	tags := []string{"api/v1.4.0", "worker/v0.9.1", "v2.0.0", "api/v1.10.0-rc1", "api/v1.2.0", "api/nope"}
+
+	expected := []string{"api/v1.4.0", "api/v1.10.0-rc1", "api/v1.2.0"}
+	got := TagPrefix("api/").FilterSemverTags(tags)
+	if reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+
+	expected = []string{"api/v1.2.0", "api/v1.4.0", "api/v1.10.0-rc1", "worker/v0.9.1", "v2.0.0", "api/nope"}
+	got = TagPrefix("api/").SortSemverTags(tags)
+	if reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+
+	expected = []string{"worker/v0.9.1", "api/v1.2.0", "api/v1.4.0", "api/v1.10.0-rc1"}
+	got = TagPrefix("*/").FilterSemverTags(TagPrefix("*/").SortSemverTags(tags))
+	if reflect.DeepEqual(got, expected) == false {
+		t.Errorf("Expected tags=%q, got tags=%q\n", expected, got)
+	}
+
+	if got := TagPrefix("api/").LatestSemverTag(TagPrefix("api/").FilterStableTags(tags)); got != "api/v1.4.0" {
+		t.Errorf("Expected latest=%q, got latest=%q\n", "api/v1.4.0", got)
+	}
+
+	tag, err := TagPrefix("*/").Parse("api/v1.4.0")
+	if err != nil {
+		t.Fatalf("Expected err=nil, got err=%s\n", err)
+	}
+	if tag.Prefix != "api/" || tag.Version.String() != "1.4.0" {
+		t.Errorf("Expected prefix=api/ version=1.4.0, got prefix=%s version=%s\n", tag.Prefix, tag.Version)
+	}
+	if _, err := TagPrefix("[").Parse("api/v1.4.0"); err == nil {
+		t.Errorf("Expected an error for an invalid prefix\n")
+	}
+
+	for _, c := range []struct{ prefix, tag, expected string }{
+		{"api/", "v1.5.0", "api/v1.5.0"},
+		{"api/", "api/v1.5.0", "api/v1.5.0"},
+		{"", "v1.5.0", "v1.5.0"},
+		{"*/", "api/v1.5.0", "api/v1.5.0"},
+	} {
+		got, err := TagPrefix(c.prefix).Tag(c.tag)
+		if err != nil || got != c.expected {
+			t.Errorf("Expected tag=%q, got tag=%q err=%v\n", c.expected, got, err)
+		}
+	}
+	if _, err := TagPrefix("*/").Tag("v1.5.0"); err == nil {
+		t.Errorf("Expected an error when a pattern prefix does not match\n")
+	}
+}
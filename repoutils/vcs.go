@@ -0,0 +1,173 @@
+package repoutils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mh-cbon/go-repo-utils/bzr"
+	"github.com/mh-cbon/go-repo-utils/darcs"
+	"github.com/mh-cbon/go-repo-utils/driver"
+	"github.com/mh-cbon/go-repo-utils/fossil"
+	"github.com/mh-cbon/go-repo-utils/git"
+	"github.com/mh-cbon/go-repo-utils/hg"
+	"github.com/mh-cbon/go-repo-utils/pijul"
+	"github.com/mh-cbon/go-repo-utils/svn"
+)
+
+// Vcs is the set of operations a vcs backend must provide.
+type Vcs = driver.Vcs
+
+// Configurable is implemented by drivers which accept a driver.Config.
+type Configurable = driver.Configurable
+
+// RootFinder is implemented by drivers which recognize a working copy root on disk.
+type RootFinder = driver.RootFinder
+
+// Walker is implemented by drivers which give the commits to a WalkFunc while the vcs prints them,
+// the commits are listed with ListCommitsBetweenContext then filtered for the other drivers.
+type Walker = driver.Walker
+
+// TagDetailer is implemented by drivers which list the tags with their details,
+// only the tag names are known for the other drivers.
+type TagDetailer = driver.TagDetailer
+
+// TimeoutError is returned when a vcs process exceeds its deadline,
+// see driver.DefaultTimeout and WithTimeout.
+type TimeoutError = driver.TimeoutError
+
+// CommandError is returned when a vcs process fails.
+type CommandError = driver.CommandError
+
+// Tag is a tag with its revision, date, tagger and annotation, see ListTagsDetailed.
+type Tag = driver.Tag
+
+// WalkFunc receives the commits of a walk one by one, see ErrStop.
+type WalkFunc = driver.WalkFunc
+
+// ErrStop is returned by a WalkFunc to end the walk early.
+var ErrStop = driver.ErrStop
+
+// ListOptions selects the commits to list, see ListCommits.
+type ListOptions = driver.ListOptions
+
+// CommitFilter selects commits by paths, author, date, message, trailers, and limits their number.
+type CommitFilter = driver.CommitFilter
+
+// Error kinds, to use with errors.Is.
+var (
+	ErrNotARepository  = driver.ErrNotARepository
+	ErrTagExists       = driver.ErrTagExists
+	ErrUnknownRevision = driver.ErrUnknownRevision
+	ErrBinaryNotFound  = driver.ErrBinaryNotFound
+	ErrUnknownVcs      = driver.ErrUnknownVcs
+	ErrTimeout         = driver.ErrTimeout
+)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Vcs{}
+)
+
+func init() {
+	Register("git", git.Driver{})
+	Register("bzr", bzr.Driver{})
+	Register("hg", hg.Driver{})
+	Register("svn", svn.Driver{})
+	Register("fossil", fossil.Driver{})
+	Register("darcs", darcs.Driver{})
+	Register("pijul", pijul.Driver{})
+}
+
+// Register makes a vcs driver available under given name.
+// Registering an existing name replaces the previous driver.
+func Register(name string, driver Vcs) {
+	if driver == nil {
+		panic("repoutils: Register driver is nil")
+	}
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = driver
+}
+
+// Unregister removes the driver registered under given name.
+func Unregister(name string) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	delete(drivers, name)
+}
+
+// Drivers returns the sorted list of registered vcs names.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetDriver returns the driver registered under given name.
+func GetDriver(vcs string) (Vcs, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	driver, ok := drivers[vcs]
+	if ok == false {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownVcs, vcs)
+	}
+	return driver, nil
+}
+
+// walkCommitsBetween calls fn with each commit of d between since and to, see walkCommits.
+func walkCommitsBetween(ctx context.Context, d Vcs, path string, since string, to string, fn WalkFunc) error {
+	if w, ok := d.(Walker); ok {
+		return w.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+	}
+	return walkCommits(ctx, d, path, ListOptions{Since: since, To: to}, fn)
+}
+
+// walkCommits calls fn with each commit of d selected by opts.
+// When d is not a Walker, the commits are listed first, then filtered,
+// the Paths filter needs the files of the commits, see driver.Config.
+func walkCommits(ctx context.Context, d Vcs, path string, opts ListOptions, fn WalkFunc) error {
+	if w, ok := d.(Walker); ok {
+		return w.WalkCommitsContext(ctx, path, opts, fn)
+	}
+	fn, err := opts.Filter(fn)
+	if err != nil {
+		return err
+	}
+	commits, err := d.ListCommitsBetweenContext(ctx, path, opts.Since, opts.To)
+	if err != nil {
+		return err
+	}
+	for _, c := range commits {
+		if len(opts.Paths) > 0 && driver.MatchPaths(opts.Paths, c.Files) == false {
+			continue
+		}
+		if err := fn(c); err != nil {
+			return driver.Stopped(err)
+		}
+	}
+	return nil
+}
+
+// listTagsDetailed lists the tags of d with their details,
+// only their names are set when d is not a TagDetailer.
+func listTagsDetailed(ctx context.Context, d Vcs, path string) ([]Tag, error) {
+	if t, ok := d.(TagDetailer); ok {
+		return t.ListTagsDetailedContext(ctx, path)
+	}
+	ret := make([]Tag, 0)
+	names, err := d.ListContext(ctx, path)
+	if err != nil {
+		return ret, err
+	}
+	for _, name := range names {
+		ret = append(ret, Tag{Name: name})
+	}
+	return ret, nil
+}
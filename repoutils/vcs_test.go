@@ -0,0 +1,96 @@
+package repoutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+// listDriver implements only Vcs, the commits are listed from the newest.
+type listDriver struct {
+	tags    []string
+	commits []commit.Commit
+}
+
+func (d listDriver) IsItContext(ctx context.Context, path string) (bool, error) { return true, nil }
+func (d listDriver) ListContext(ctx context.Context, path string) ([]string, error) {
+	return d.tags, nil
+}
+func (d listDriver) IsCleanContext(ctx context.Context, path string) (bool, error) { return true, nil }
+func (d listDriver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return true, "", nil
+}
+func (d listDriver) AddContext(ctx context.Context, path string, file string) error { return nil }
+func (d listDriver) CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return nil
+}
+func (d listDriver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return d.commits, nil
+}
+func (d listDriver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return "a", nil
+}
+
+func TestWalkCommitsFallback(t *testing.T) {
+	d := listDriver{commits: []commit.Commit{
+		{Revision: "d", Author: "john", Message: "fix: d", Files: []commit.File{{Path: "lib/d.go"}}},
+		{Revision: "c", Author: "jane", Message: "feat: c", Files: []commit.File{{Path: "c.go"}}},
+		{Revision: "b", Author: "john", Message: "feat: b", Files: []commit.File{{Path: "lib/b.go"}}},
+		{Revision: "a", Author: "john", Message: "feat: a", Files: []commit.File{{Path: "lib/a.go"}}},
+	}}
+	Register("listvcs", d)
+	defer Unregister("listvcs")
+
+	cases := []struct {
+		opts ListOptions
+		want string
+	}{
+		{ListOptions{}, "dcba"},
+		{ListOptions{CommitFilter: CommitFilter{Author: "JOHN"}}, "dba"},
+		{ListOptions{CommitFilter: CommitFilter{Message: "^feat"}}, "cba"},
+		{ListOptions{CommitFilter: CommitFilter{Paths: []string{"lib"}}}, "dba"},
+		{ListOptions{CommitFilter: CommitFilter{Paths: []string{"lib"}, Skip: 1, MaxCount: 1}}, "b"},
+	}
+	for _, c := range cases {
+		commits, err := ListCommits("listvcs", "/tmp", c.opts)
+		if err != nil {
+			t.Errorf("Expected err=nil, got err=%s\n", err)
+		}
+		got := ""
+		for _, c := range commits {
+			got += c.Revision
+		}
+		if got != c.want {
+			t.Errorf("Expected commits=%q, got commits=%q\n", c.want, got)
+		}
+	}
+
+	got := ""
+	err := WalkCommitsBetween("listvcs", "/tmp", "", "", func(c commit.Commit) error {
+		got += c.Revision
+		if len(got) == 2 {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if got != "dc" {
+		t.Errorf("Expected commits=%q, got commits=%q\n", "dc", got)
+	}
+}
+
+func TestListTagsDetailedFallback(t *testing.T) {
+	Register("listvcs", listDriver{tags: []string{"v1.0.0", "v1.1.0"}})
+	defer Unregister("listvcs")
+
+	tags, err := ListTagsDetailed("listvcs", "/tmp")
+	if err != nil {
+		t.Errorf("Expected err=nil, got err=%s\n", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "v1.0.0" || tags[1].Name != "v1.1.0" || tags[0].Revision != "" {
+		t.Errorf("Expected tags v1.0.0 and v1.1.0 with names only, got %v\n", tags)
+	}
+}
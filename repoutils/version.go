@@ -0,0 +1,190 @@
+package repoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/commit/conventional"
+)
+
+// Bump is a semver increment, see NextVersion.
+type Bump string
+
+// The increments of a version.
+const (
+	BumpNone       Bump = "none"
+	BumpPatch      Bump = "patch"
+	BumpMinor      Bump = "minor"
+	BumpMajor      Bump = "major"
+	BumpPrerelease Bump = "prerelease"
+)
+
+// ErrInvalidBump is returned for an unknown Bump.
+var ErrInvalidBump = errors.New("invalid bump, expected one of major, minor, patch, prerelease")
+
+// ParseBump returns the Bump named s, one of major, minor, patch or prerelease.
+func ParseBump(s string) (Bump, error) {
+	for _, b := range []Bump{BumpMajor, BumpMinor, BumpPatch, BumpPrerelease} {
+		if string(b) == s {
+			return b, nil
+		}
+	}
+	return BumpNone, fmt.Errorf("%w: %q", ErrInvalidBump, s)
+}
+
+// BumpOf returns the increment the commits call for as Conventional Commits:
+// major for a breaking change, minor for a feature, patch for the other commits,
+// BumpNone when there are no commits.
+func BumpOf(commits []commit.Commit) Bump {
+	bump := BumpNone
+	for _, c := range commits {
+		cc, err := conventional.Parse(c.Message)
+		if err == nil && cc.Breaking {
+			return BumpMajor
+		} else if err == nil && cc.Type == "feat" {
+			bump = BumpMinor
+		} else if bump == BumpNone {
+			bump = BumpPatch
+		}
+	}
+	return bump
+}
+
+// NextVersionOptions configures NextVersion.
+type NextVersionOptions struct {
+	// Prefix selects the tags of the versions, see TagPrefix.
+	Prefix TagPrefix
+	// Bump forces the increment, it is computed from the commits with BumpOf when it is empty.
+	Bump Bump
+	// Preid is the identifier of the prerelease versions, rc when it is empty.
+	Preid string
+}
+
+// VersionBump is the next version of a repository.
+type VersionBump struct {
+	// Previous is the latest semver tag, empty when there is none.
+	Previous string `json:"previous"`
+	// Next is the tag of the next version, Previous when there is nothing to release.
+	Next    string `json:"next"`
+	Bump    Bump   `json:"bump"`
+	Commits int    `json:"commits"`
+}
+
+// NextVersion returns the next version of the repository at path according to given vcs,
+// see Repo.NextVersion.
+func NextVersion(vcs string, path string, opts NextVersionOptions) (VersionBump, error) {
+	return NextVersionContext(context.Background(), vcs, path, opts)
+}
+
+// NextVersionContext is like NextVersion, bounded by ctx.
+func NextVersionContext(ctx context.Context, vcs string, path string, opts NextVersionOptions) (VersionBump, error) {
+	driver, err := GetDriver(vcs)
+	if err != nil {
+		return VersionBump{}, err
+	}
+	return nextVersion(ctx, driver, path, opts)
+}
+
+func nextVersion(ctx context.Context, driver Vcs, path string, opts NextVersionOptions) (VersionBump, error) {
+	tags, err := driver.ListContext(ctx, path)
+	if err != nil {
+		return VersionBump{}, err
+	}
+	previous := opts.Prefix.LatestSemverTag(tags)
+	commits, err := driver.ListCommitsBetweenContext(ctx, path, previous, "HEAD")
+	if err != nil {
+		return VersionBump{}, err
+	}
+	return NextVersionOf(previous, commits, opts)
+}
+
+// NextVersionOf returns the version following the tag previous, given the commits since it.
+// Without previous tag, the version is incremented from 0.0.0.
+// The next version keeps the prefix and the v of previous.
+// A prerelease is released by the increment it already contains, 1.3.0-rc.1 is followed by 1.3.0
+// for a minor or patch increment, a prerelease increment numbers it, 1.3.0-rc.2.
+// A prerelease increment of a release applies the increment of the commits first, 1.3.0-rc.1
+// follows 1.2.0 and a feature.
+func NextVersionOf(previous string, commits []commit.Commit, opts NextVersionOptions) (VersionBump, error) {
+	ret := VersionBump{Previous: previous, Next: previous, Bump: opts.Bump, Commits: len(commits)}
+	changes := BumpOf(commits)
+	if ret.Bump == "" {
+		ret.Bump = changes
+	}
+	if ret.Bump == BumpNone {
+		return ret, nil
+	}
+
+	current := SemverTag{Version: semver.MustParse("0.0.0")}
+	if previous != "" {
+		t, err := opts.Prefix.Parse(previous)
+		if err != nil {
+			return ret, err
+		}
+		current = t
+	}
+
+	v := *current.Version
+	var err error
+	switch ret.Bump {
+	case BumpMajor, BumpMinor, BumpPatch:
+		v = increment(v, ret.Bump)
+	case BumpPrerelease:
+		preid := opts.Preid
+		if preid == "" {
+			preid = "rc"
+		}
+		if v.Prerelease() != "" {
+			v, err = v.SetPrerelease(nextPrerelease(v.Prerelease()))
+		} else {
+			if changes == BumpNone {
+				changes = BumpPatch
+			}
+			v, err = increment(v, changes).SetPrerelease(preid + ".1")
+		}
+		if err != nil {
+			return ret, fmt.Errorf("invalid prerelease identifier %q: %w", preid, err)
+		}
+	default:
+		return ret, fmt.Errorf("%w: %q", ErrInvalidBump, ret.Bump)
+	}
+
+	if previous == "" {
+		ret.Next, err = opts.Prefix.Tag(v.String())
+		return ret, err
+	}
+	ret.Next = current.Prefix + v.Original()
+	return ret, nil
+}
+
+// increment returns v incremented by bump, a prerelease is released when it contains the increment.
+func increment(v semver.Version, bump Bump) semver.Version {
+	if v.Prerelease() != "" {
+		if bump == BumpPatch || (bump == BumpMinor && v.Patch() == 0) ||
+			(bump == BumpMajor && v.Minor() == 0 && v.Patch() == 0) {
+			return v.IncPatch()
+		}
+	}
+	if bump == BumpMajor {
+		return v.IncMajor()
+	} else if bump == BumpMinor {
+		return v.IncMinor()
+	}
+	return v.IncPatch()
+}
+
+// nextPrerelease increments the last numeric identifier of pre, rc.1 is followed by rc.2,
+// a .1 identifier is appended when the last one is not numeric.
+func nextPrerelease(pre string) string {
+	ids := strings.Split(pre, ".")
+	if n, err := strconv.ParseInt(ids[len(ids)-1], 10, 64); err == nil {
+		ids[len(ids)-1] = strconv.FormatInt(n+1, 10)
+		return strings.Join(ids, ".")
+	}
+	return pre + ".1"
+}
@@ -0,0 +1,64 @@
+package repoutils
+
+import (
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestBumpOf(t *testing.T) {
+	for _, c := range []struct {
+		messages []string
+		expected Bump
+	}{
+		{nil, BumpNone},
+		{[]string{"update the docs"}, BumpPatch},
+		{[]string{"fix: a bug", "feat(cli): a flag"}, BumpMinor},
+		{[]string{"feat: a flag", "refactor!: the api"}, BumpMajor},
+		{[]string{"fix: a bug\n\nBREAKING CHANGE: the api changed"}, BumpMajor},
+	} {
+		commits := []commit.Commit{}
+		for _, m := range c.messages {
+			commits = append(commits, commit.Commit{Message: m})
+		}
+		if got := BumpOf(commits); got != c.expected {
+			t.Errorf("Expected bump=%q for %q, got bump=%q\n", c.expected, c.messages, got)
+		}
+	}
+}
+
+func TestNextVersionOf(t *testing.T) {
+	feat := []commit.Commit{{Message: "fix: a bug"}, {Message: "feat: a flag"}}
+	fix := []commit.Commit{{Message: "fix: a bug"}}
+	for _, c := range []struct {
+		previous string
+		commits  []commit.Commit
+		opts     NextVersionOptions
+		expected string
+	}{
+		{"v1.2.3", feat, NextVersionOptions{}, "v1.3.0"},
+		{"1.2.3", fix, NextVersionOptions{}, "1.2.4"},
+		{"1.2.3", nil, NextVersionOptions{}, "1.2.3"},
+		{"1.2.3", fix, NextVersionOptions{Bump: BumpMajor}, "2.0.0"},
+		{"v1.2.3", feat, NextVersionOptions{Bump: BumpPrerelease}, "v1.3.0-rc.1"},
+		{"v1.3.0-rc.1", fix, NextVersionOptions{Bump: BumpPrerelease}, "v1.3.0-rc.2"},
+		{"v1.3.0-rc.1", feat, NextVersionOptions{}, "v1.3.0"},
+		{"v1.3.1-rc.1", feat, NextVersionOptions{}, "v1.4.0"},
+		{"1.2.3", fix, NextVersionOptions{Bump: BumpPrerelease, Preid: "beta"}, "1.2.4-beta.1"},
+		{"api/v1.4.0", feat, NextVersionOptions{Prefix: "api/"}, "api/v1.5.0"},
+		{"", feat, NextVersionOptions{Prefix: "api/v"}, "api/v0.1.0"},
+		{"", fix, NextVersionOptions{}, "0.0.1"},
+	} {
+		got, err := NextVersionOf(c.previous, c.commits, c.opts)
+		if err != nil {
+			t.Errorf("Expected err=nil, got err=%s\n", err)
+		}
+		if got.Next != c.expected {
+			t.Errorf("Expected next=%q after %q, got next=%q\n", c.expected, c.previous, got.Next)
+		}
+	}
+
+	if _, err := NextVersionOf("", feat, NextVersionOptions{Prefix: "*/"}); err == nil {
+		t.Errorf("Expected an error for a pattern prefix without previous tag\n")
+	}
+}
@@ -2,65 +2,164 @@
 package svn
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mh-cbon/go-repo-utils/commit"
+	"github.com/mh-cbon/go-repo-utils/driver"
 	"github.com/mh-cbon/verbose"
 )
 
 var logger = verbose.Auto()
 
-func getCmd(path string, args []string) (*exec.Cmd, error) {
-	bin, err := exec.LookPath("svn")
+// Driver implements driver.Vcs, driver.Walker and driver.TagDetailer for svn.
+type Driver struct {
+	driver.Config
+}
+
+// Configure returns a svn driver using given config.
+func (d Driver) Configure(c driver.Config) driver.Vcs {
+	return Driver{Config: c}
+}
+
+func (d Driver) logger() driver.Logger {
+	return d.LoggerOr(logger)
+}
+
+func (d Driver) getCmd(ctx context.Context, path string, args []string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(d.BinOr("svn"))
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return nil, err
+		d.logger().Printf("err=%s", err)
+		return nil, driver.BinaryNotFound(err)
 	}
-	logger.Printf("%s %s", bin, args)
-	cmd := exec.Command(bin, args...)
+	d.logger().Printf("%s %s (cwd=%s)", bin, args, path)
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Dir = path
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
 	return cmd, nil
 }
 
-// IsIt Tests if path is managed by SVN using svn list
-func IsIt(path string) bool {
+func (d Driver) run(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
 
-	args := []string{"list"}
-	cmd, err := getCmd(path, args)
+	cmd, err := d.getCmd(ctx, path, args)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	out, err := cmd.CombinedOutput()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	d.logger().Printf("err=%s", err)
+	d.logger().Printf("out=%s%s", stdout.String(), stderr.String())
+	return stdout.Bytes(), driver.RunError(ctx, cmd, stdout.Bytes(), stderr.Bytes(), err, classify)
+}
+
+// stream runs svn and gives its output to read while it is produced.
+func (d Driver) stream(ctx context.Context, path string, args []string, read func(r io.Reader) error) error {
+	ctx, cancel := d.Context(ctx)
+	defer cancel()
+
+	cmd, err := d.getCmd(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
+		return err
+	}
+
+	err = driver.Stream(ctx, cancel, cmd, read, classify)
+	d.logger().Printf("err=%s", err)
+	return err
+}
+
+// classify maps svn failures to the driver error kinds.
+func classify(stderr string) error {
+	switch {
+	case strings.Contains(stderr, "E155007"), strings.Contains(stderr, "is not a working copy"):
+		return driver.ErrNotARepository
+	case strings.Contains(stderr, "E160020"), strings.Contains(stderr, "already exists"):
+		return driver.ErrTagExists
+	case strings.Contains(stderr, "E160006"), strings.Contains(stderr, "E160013"),
+		strings.Contains(stderr, "No such revision"):
+		return driver.ErrUnknownRevision
+	}
+	return nil
+}
+
+// IsIt Tests if path is managed by SVN using svn list
+func IsIt(path string) bool {
+	ok, _ := Driver{}.IsItContext(context.Background(), path)
+	return ok
+}
+
+// IsItContext is like IsIt, bounded by ctx.
+func IsItContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsItContext(ctx, path)
+}
+
+// IsItContext Tests if path is managed by SVN using svn list,
+// the error is only set when the probe timed out or was canceled.
+func (d Driver) IsItContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"list"}
+	_, err := d.run(ctx, path, args)
+	return err == nil, driver.ProbeError(err)
+}
+
+// IsRoot tells if dir is the top of an svn working copy.
+func IsRoot(dir string) bool {
+	return Driver{}.IsRoot(dir)
+}
+
+// IsRoot tells if dir is the top of an svn working copy.
+// Since svn 1.7 only the top directory holds a .svn directory,
+// older working copies have one in each directory, thus the parent is checked too.
+func (d Driver) IsRoot(dir string) bool {
+	if hasSvnDir(dir) == false {
 		return false
 	}
+	parent := filepath.Dir(dir)
+	return parent == dir || hasSvnDir(parent) == false
+}
 
-	logger.Printf("out=%s", string(out))
-	return cmd.ProcessState != nil && cmd.ProcessState.Success()
+func hasSvnDir(dir string) bool {
+	s, err := os.Stat(filepath.Join(dir, ".svn"))
+	return err == nil && s.IsDir()
 }
 
 // List svn tags with svn ls ^/tags of given path
 func List(path string) ([]string, error) {
+	return Driver{}.ListContext(context.Background(), path)
+}
+
+// ListContext is like List, bounded by ctx.
+func ListContext(ctx context.Context, path string) ([]string, error) {
+	return Driver{}.ListContext(ctx, path)
+}
+
+// ListContext lists svn tags with svn ls ^/tags of given path
+func (d Driver) ListContext(ctx context.Context, path string) ([]string, error) {
 	tags := make([]string, 0)
 
 	args := []string{"ls", "^/tags"}
-	cmd, err := getCmd(path, args)
+	out, err := d.run(ctx, path, args)
 	if err != nil {
 		return tags, err
 	}
 
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Printf("err=%s", err)
-		return tags, err
-	}
-
-	logger.Printf("out=%s", string(out))
 	for _, v := range strings.Split(string(out), "\n") {
 		if len(v) > 0 {
 			tags = append(tags, v[0:len(v)-1])
@@ -69,90 +168,164 @@ func List(path string) ([]string, error) {
 	return tags, nil
 }
 
-// IsClean Checks uncommited files with svn -q of given path
-func IsClean(path string) (bool, error) {
+// ListTagsDetailed lists the tags on given path with the revisions they copied.
+func ListTagsDetailed(path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(context.Background(), path)
+}
 
-	args := []string{"status", "-q"}
-	cmd, err := getCmd(path, args)
+// ListTagsDetailedContext is like ListTagsDetailed, bounded by ctx.
+func ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	return Driver{}.ListTagsDetailedContext(ctx, path)
+}
+
+// ListTagsDetailedContext lists the tags on given path, from the log of ^/tags,
+// they are annotated by the commit which copied them.
+func (d Driver) ListTagsDetailedContext(ctx context.Context, path string) ([]driver.Tag, error) {
+	ret := make([]driver.Tag, 0)
+	names, err := d.ListContext(ctx, path)
+	if err != nil || len(names) == 0 {
+		return ret, err
+	}
+
+	out, err := d.run(ctx, path, []string{"log", "--xml", "-v", "^/tags"})
 	if err != nil {
-		return false, err
+		return ret, err
+	}
+	tags, err := ParseSvnTagsLog(string(out))
+	if err != nil {
+		return ret, err
+	}
+
+	revs := []string{}
+	for _, name := range names {
+		if t, ok := tags[name]; ok && contains(revs, t.Revision) == false {
+			revs = append(revs, t.Revision)
+		}
+	}
+	dates := map[string]time.Time{}
+	if len(revs) > 0 {
+		err = d.stream(ctx, path, []string{"log", "--xml", "-c", strings.Join(revs, ","), "^/"}, func(r io.Reader) error {
+			return walkSvnXMLLog(r, func(c commit.Commit) error {
+				dates[c.Revision] = c.Time
+				return nil
+			})
+		})
+		if err != nil {
+			return ret, err
+		}
 	}
 
-	out, err := cmd.CombinedOutput()
+	for _, name := range names {
+		t, ok := tags[name]
+		if ok == false {
+			t = driver.Tag{Name: name}
+		}
+		t.Date = dates[t.Revision]
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+// IsClean Checks uncommited files with svn -q of given path
+func IsClean(path string) (bool, error) {
+	return Driver{}.IsCleanContext(context.Background(), path)
+}
+
+// IsCleanContext is like IsClean, bounded by ctx.
+func IsCleanContext(ctx context.Context, path string) (bool, error) {
+	return Driver{}.IsCleanContext(ctx, path)
+}
+
+// IsCleanContext Checks uncommited files with svn -q of given path
+func (d Driver) IsCleanContext(ctx context.Context, path string) (bool, error) {
+
+	args := []string{"status", "-q"}
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	return len(string(out)) == 0, nil
 }
 
 // CreateTag Creates given tag at root/tags/[tag] on path with the provided message
 func CreateTag(path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(context.Background(), path, tag, message)
+}
+
+// CreateTagContext is like CreateTag, bounded by ctx.
+func CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+	return Driver{}.CreateTagContext(ctx, path, tag, message)
+}
 
-	tags, err := List(path)
+// CreateTagContext Creates given tag at root/tags/[tag] on path with the provided message
+func (d Driver) CreateTagContext(ctx context.Context, path string, tag string, message string) (bool, string, error) {
+
+	tags, err := d.ListContext(ctx, path)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, "", err
 	}
 
 	if contains(tags, tag) {
-		return false, "", errors.New("Tag '" + tag + "' already exists")
+		return false, "", fmt.Errorf("%w: %s", driver.ErrTagExists, tag)
 	}
 
-	root, err := GetRepositoryRoot(path)
+	root, err := d.GetRepositoryRootContext(ctx, path)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return false, "", err
 	}
 
-	CreateTagDir(path)
+	d.CreateTagDirContext(ctx, path)
 
 	args := []string{"copy", root + "/trunk", root + "/tags/" + tag}
 	if len(message) > 0 {
 		args = append(args, []string{"-m", message}...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return false, "", err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	out, err := d.run(ctx, path, args)
 	return err == nil, string(out), err
 }
 
 // CreateTagDir Create an svn tag directory at root/tags/
 func CreateTagDir(path string) (string, error) {
-	root, err := GetRepositoryRoot(path)
-	if err != nil {
-		logger.Printf("err=%s", err)
-		return "", err
-	}
+	return Driver{}.CreateTagDirContext(context.Background(), path)
+}
 
-	args := []string{"mkdir", root + "/tags/", "-m", "Create tag folder"}
-	cmd, err := getCmd(path, args)
+// CreateTagDirContext is like CreateTagDir, bounded by ctx.
+func CreateTagDirContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.CreateTagDirContext(ctx, path)
+}
+
+// CreateTagDirContext Create an svn tag directory at root/tags/
+func (d Driver) CreateTagDirContext(ctx context.Context, path string) (string, error) {
+	root, err := d.GetRepositoryRootContext(ctx, path)
 	if err != nil {
 		return "", err
 	}
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	args := []string{"mkdir", root + "/tags/", "-m", "Create tag folder"}
+	out, err := d.run(ctx, path, args)
 	return string(out), err
 }
 
 // GetRepositoryRoot returns svn root path according to svn info .
 func GetRepositoryRoot(path string) (string, error) {
+	return Driver{}.GetRepositoryRootContext(context.Background(), path)
+}
+
+// GetRepositoryRootContext is like GetRepositoryRoot, bounded by ctx.
+func GetRepositoryRootContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetRepositoryRootContext(ctx, path)
+}
+
+// GetRepositoryRootContext returns svn root path according to svn info .
+func (d Driver) GetRepositoryRootContext(ctx context.Context, path string) (string, error) {
 
-	d, err := GetRepositoryInfo(path)
+	info, err := d.GetRepositoryInfoContext(ctx, path)
 	if err != nil {
 		return "", err
 	}
 
-	if x, ok := d["Repository Root"]; ok {
+	if x, ok := info["Repository Root"]; ok {
 		return x, nil
 	}
 	return "", nil
@@ -160,22 +333,25 @@ func GetRepositoryRoot(path string) (string, error) {
 
 // GetRepositoryInfo parses svn info to a map.
 func GetRepositoryInfo(path string) (map[string]string, error) {
+	return Driver{}.GetRepositoryInfoContext(context.Background(), path)
+}
+
+// GetRepositoryInfoContext is like GetRepositoryInfo, bounded by ctx.
+func GetRepositoryInfoContext(ctx context.Context, path string) (map[string]string, error) {
+	return Driver{}.GetRepositoryInfoContext(ctx, path)
+}
+
+// GetRepositoryInfoContext parses svn info to a map.
+func (d Driver) GetRepositoryInfoContext(ctx context.Context, path string) (map[string]string, error) {
 
 	ret := map[string]string{}
 
 	args := []string{"info", "."}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return ret, err
-	}
-
-	out, err := cmd.CombinedOutput()
+	out, err := d.run(ctx, path, args)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return ret, err
 	}
 
-	logger.Printf("out=%s", string(out))
 	ID := regexp.MustCompile(`^([^:]+):(.+)`)
 	for _, line := range strings.Split(string(out), "\n") {
 		if ID.MatchString(line) {
@@ -189,21 +365,34 @@ func GetRepositoryInfo(path string) (map[string]string, error) {
 
 // Add given file to svn on path
 func Add(path string, file string) error {
+	return Driver{}.AddContext(context.Background(), path, file)
+}
 
-	args := []string{"add", file}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
+// AddContext is like Add, bounded by ctx.
+func AddContext(ctx context.Context, path string, file string) error {
+	return Driver{}.AddContext(ctx, path, file)
+}
+
+// AddContext adds given file to svn on path
+func (d Driver) AddContext(ctx context.Context, path string, file string) error {
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	args := []string{"add", file}
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
 // Commit given files with message on path
 func Commit(path string, message string, files []string) error {
+	return Driver{}.CommitContext(context.Background(), path, message, files)
+}
+
+// CommitContext is like Commit, bounded by ctx.
+func CommitContext(ctx context.Context, path string, message string, files []string) error {
+	return Driver{}.CommitContext(ctx, path, message, files)
+}
+
+// CommitContext commits given files with message on path
+func (d Driver) CommitContext(ctx context.Context, path string, message string, files []string) error {
 
 	if len(message) == 0 {
 		return errors.New("Message is required")
@@ -213,14 +402,7 @@ func Commit(path string, message string, files []string) error {
 	if len(files) > 0 {
 		args = append(args, files...)
 	}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	_, err := d.run(ctx, path, args)
 	return err
 }
 
@@ -235,29 +417,70 @@ func contains(s []string, e string) bool {
 
 // ListCommitsBetween List commits between two points
 func ListCommitsBetween(path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(context.Background(), path, since, to)
+}
+
+// ListCommitsBetweenContext is like ListCommitsBetween, bounded by ctx.
+func ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
+	return Driver{}.ListCommitsBetweenContext(ctx, path, since, to)
+}
+
+// ListCommitsBetweenContext List commits between two points
+func (d Driver) ListCommitsBetweenContext(ctx context.Context, path string, since string, to string) ([]commit.Commit, error) {
 	ret := make([]commit.Commit, 0)
+	err := d.WalkCommitsBetweenContext(ctx, path, since, to, func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// WalkCommitsBetween calls fn with each commit between two points, while svn log prints them
+func WalkCommitsBetween(path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(context.Background(), path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext is like WalkCommitsBetween, bounded by ctx.
+func WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsBetweenContext(ctx, path, since, to, fn)
+}
+
+// WalkCommitsBetweenContext calls fn with each commit between two points, while svn log prints them
+func (d Driver) WalkCommitsBetweenContext(ctx context.Context, path string, since string, to string, fn driver.WalkFunc) error {
+	return d.WalkCommitsContext(ctx, path, driver.ListOptions{Since: since, To: to}, fn)
+}
 
-	tags, err := List(path)
+// WalkCommits calls fn with each commit selected by opts, while svn log prints them
+func WalkCommits(path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(context.Background(), path, opts, fn)
+}
+
+// WalkCommitsContext is like WalkCommits, bounded by ctx.
+func WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	return Driver{}.WalkCommitsContext(ctx, path, opts, fn)
+}
+
+// WalkCommitsContext calls fn with each commit selected by opts, while svn log prints them
+func (d Driver) WalkCommitsContext(ctx context.Context, path string, opts driver.ListOptions, fn driver.WalkFunc) error {
+	since, to := opts.Since, opts.To
+	tags, err := d.ListContext(ctx, path)
 	if err != nil {
-		logger.Printf("err=%s", err)
-		return ret, err
+		return err
 	}
 
 	if p := pos(tags, since); p > -1 {
-		s, err2 := GetRevisionTag(path, since)
+		s, err2 := d.GetRevisionTagContext(ctx, path, since)
 		if err2 != nil {
-			logger.Printf("err=%s", err2)
-			return ret, err2
+			return err2
 		}
 		if s != "" {
 			since = s
 		}
 	}
 	if p := pos(tags, to); p > -1 {
-		t, err2 := GetRevisionTag(path, to)
+		t, err2 := d.GetRevisionTagContext(ctx, path, to)
 		if err2 != nil {
-			logger.Printf("err=%s", err2)
-			return ret, err2
+			return err2
 		}
 		if t != "" {
 			to = t
@@ -267,37 +490,250 @@ func ListCommitsBetween(path string, since string, to string) ([]commit.Commit,
 		since = "0"
 	}
 
-	args := []string{"log"}
+	revs := []string{}
 	if len(since)+len(to) > 0 {
-		args = append(args, "-r", since+":"+to)
+		revs = append(revs, "-r", since+":"+to)
 	}
-	args = append(args, "^/.")
-	cmd, err := getCmd(path, args)
+
+	branch, url := "", ""
+	if info, err2 := d.GetRepositoryInfoContext(ctx, path); err2 == nil {
+		branch = Branch(info["Relative URL"])
+		url = info["Relative URL"]
+	}
+
+	filter := opts.CommitFilter
+	if len(filter.Paths) > 0 {
+		// svn log URL PATH... lists the changes of the paths relative to URL
+		if url == "" {
+			return fmt.Errorf("%w: no repository url for %s", driver.ErrNotARepository, path)
+		}
+		revs = append(revs, url)
+		revs = append(revs, filter.Paths...)
+		filter.Paths = nil
+	} else {
+		revs = append(revs, "^/.")
+	}
+	if n := filter.Limit(); n > 0 {
+		revs = append([]string{"-l", strconv.Itoa(n)}, revs...)
+	}
+	fn, err = filter.Filter(fn)
 	if err != nil {
-		return ret, err
+		return err
+	}
+
+	args := []string{"log", "--xml"}
+	if d.Files {
+		args = append(args, "-v")
 	}
+	args = append(args, revs...)
 
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	walked := false
+	walk := func(c commit.Commit) error {
+		walked = true
+		c.Branch = branch
+		c.Complete()
+		return fn(c)
+	}
 
-	ret = ParseSvnLog(string(out))
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkSvnXMLLog(r, walk)
+	})
+	if err == nil || walked || (driver.Unrecognized(err) == false && errors.Is(err, errUnexpectedLog) == false) {
+		return driver.Stopped(err)
+	}
+
+	args = append([]string{"log"}, revs...)
+	err = d.stream(ctx, path, args, func(r io.Reader) error {
+		return walkSvnLog(r, walk)
+	})
+	return driver.Stopped(err)
+}
+
+// Branch returns the name of the branch of a relative url such as ^/branches/name,
+// it is trunk for ^/trunk.
+func Branch(relativeURL string) string {
+	k := strings.Split(strings.TrimPrefix(relativeURL, "^/"), "/")
+	if k[0] == "trunk" {
+		return "trunk"
+	}
+	if (k[0] == "branches" || k[0] == "tags") && len(k) > 1 {
+		return k[1]
+	}
+	return ""
+}
+
+type xmlLogEntry struct {
+	Revision string    `xml:"revision,attr"`
+	Author   string    `xml:"author"`
+	Date     string    `xml:"date"`
+	Msg      string    `xml:"msg"`
+	Paths    []xmlPath `xml:"paths>path"`
+}
 
+type xmlPath struct {
+	Action      string `xml:"action,attr"`
+	CopyFrom    string `xml:"copyfrom-path,attr"`
+	CopyFromRev string `xml:"copyfrom-rev,attr"`
+	Path        string `xml:",chardata"`
+}
+
+// files returns the changed paths of e, a copy whose source is deleted is a rename.
+func (e xmlLogEntry) files() []commit.File {
+	var files []commit.File
+	deleted := map[string]bool{}
+	for _, p := range e.Paths {
+		if p.Action == "D" {
+			deleted[p.Path] = true
+		}
+	}
+	renamed := map[string]bool{}
+	for _, p := range e.Paths {
+		if p.Action == "A" && deleted[p.CopyFrom] {
+			renamed[p.CopyFrom] = true
+		}
+	}
+	for _, p := range e.Paths {
+		f := commit.File{Action: commit.Modified, Path: p.Path}
+		switch p.Action {
+		case "A":
+			f.Action = commit.Added
+			if renamed[p.CopyFrom] {
+				f.Action = commit.Renamed
+				f.From = p.CopyFrom
+			}
+		case "D":
+			if renamed[p.Path] {
+				continue
+			}
+			f.Action = commit.Deleted
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// ParseSvnXMLLog parses an svn log --xml string to a list of commits,
+// the parent of a revision is the previous revision of the repository.
+func ParseSvnXMLLog(log string) ([]commit.Commit, error) {
+	ret := make([]commit.Commit, 0)
+	err := walkSvnXMLLog(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret, err
+}
+
+// errUnexpectedLog is returned when the output of svn log --xml can not be decoded.
+var errUnexpectedLog = errors.New("Unexpected svn log output")
+
+// walkSvnXMLLog calls fn with each log entry of r while it is decoded, see ParseSvnXMLLog.
+func walkSvnXMLLog(r io.Reader, fn driver.WalkFunc) error {
+	return walkSvnXMLEntries(r, func(e xmlLogEntry) error {
+		c := commit.Commit{
+			Revision: e.Revision,
+			Author:   strings.TrimSpace(e.Author),
+			Date:     e.Date,
+			Message:  strings.TrimSpace(e.Msg),
+		}
+		if d, err := time.Parse(time.RFC3339Nano, e.Date); err == nil {
+			c.Time = d
+		}
+		if rev, err := strconv.Atoi(e.Revision); err == nil && rev > 1 {
+			c.Parents = []string{strconv.Itoa(rev - 1)}
+		}
+		c.Files = e.files()
+		c.Complete()
+		return fn(c)
+	})
+}
+
+// walkSvnXMLEntries calls fn with each log entry of r while it is decoded.
+func walkSvnXMLEntries(r io.Reader, fn func(e xmlLogEntry) error) error {
+	dec := xml.NewDecoder(r)
+	isLog := false
+	for {
+		t, err := dec.Token()
+		if err == io.EOF && isLog {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", errUnexpectedLog, err)
+		}
+		start, ok := t.(xml.StartElement)
+		if ok == false {
+			continue
+		}
+		if start.Name.Local == "log" {
+			isLog = true
+			continue
+		}
+		if start.Name.Local != "logentry" {
+			continue
+		}
+		var e xmlLogEntry
+		if err := dec.DecodeElement(&e, &start); err != nil {
+			return fmt.Errorf("%w: %v", errUnexpectedLog, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// ParseSvnTagsLog parses the svn log --xml -v output of ^/tags,
+// each tag is the last copy to /tags/name, its revision is the copied revision.
+// The dates of the tagged revisions are not set.
+func ParseSvnTagsLog(log string) (map[string]driver.Tag, error) {
+	ret := map[string]driver.Tag{}
+	err := walkSvnXMLEntries(strings.NewReader(log), func(e xmlLogEntry) error {
+		for _, p := range e.Paths {
+			name := strings.TrimPrefix(p.Path, "/tags/")
+			if p.Action != "A" || name == p.Path || name == "" || strings.Contains(name, "/") {
+				continue
+			}
+			if _, ok := ret[name]; ok {
+				continue
+			}
+			t := driver.Tag{
+				Name:      name,
+				Revision:  p.CopyFromRev,
+				Tagger:    strings.TrimSpace(e.Author),
+				Message:   strings.TrimSpace(e.Msg),
+				Annotated: true,
+			}
+			if t.Revision == "" {
+				t.Revision = e.Revision
+			}
+			t.TaggerTime, _ = commit.ParseDate(e.Date)
+			ret[name] = t
+		}
+		return nil
+	})
 	return ret, err
 }
 
-// ParseSvnLog parses an svn log string to a list of commits.
+// ParseSvnLog parses an svn log string to a list of commits,
+// it is used when svn log --xml can not be parsed.
 func ParseSvnLog(log string) []commit.Commit {
 	ret := make([]commit.Commit, 0)
+	walkSvnLog(strings.NewReader(log), func(c commit.Commit) error {
+		ret = append(ret, c)
+		return nil
+	})
+	return ret
+}
 
+// walkSvnLog calls fn with each commit of r, see ParseSvnLog.
+func walkSvnLog(r io.Reader, fn driver.WalkFunc) error {
 	splitRe := regexp.MustCompile(`^[-]+$`)
 	infoRe := regexp.MustCompile(`^r([0-9]+)\s+\|\s+([^|]+)\|\s+([^\(]+)`)
 	var c *commit.Commit
-	for _, line := range strings.Split(log, "\n") {
+	err := driver.Lines(r, func(line string) error {
 		line = strings.TrimSpace(line)
 		if splitRe.MatchString(line) {
 			if c != nil {
-				ret = append(ret, *c)
+				if err := fn(*c); err != nil {
+					return err
+				}
 			}
 			c = &commit.Commit{}
 		} else if c != nil && c.Revision == "" && infoRe.MatchString(line) {
@@ -312,32 +748,35 @@ func ParseSvnLog(log string) []commit.Commit {
 				c.Message = c.Message + "\n" + line
 			}
 		}
+		return nil
+	})
+	if err == nil && c != nil && c.Revision != "" {
+		err = fn(*c)
 	}
-	if c != nil && c.Revision != "" {
-		ret = append(ret, *c)
-	}
-	return ret
+	return err
 }
 
 // GetRevisionTag Get the revision of a tag
 func GetRevisionTag(path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(context.Background(), path, tag)
+}
+
+// GetRevisionTagContext is like GetRevisionTag, bounded by ctx.
+func GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
+	return Driver{}.GetRevisionTagContext(ctx, path, tag)
+}
+
+// GetRevisionTagContext Get the revision of a tag
+func (d Driver) GetRevisionTagContext(ctx context.Context, path string, tag string) (string, error) {
 	ret := ""
 
-	root, err := GetRepositoryRoot(path)
+	root, err := d.GetRepositoryRootContext(ctx, path)
 	if err != nil {
-		logger.Printf("err=%s", err)
 		return ret, err
 	}
 
 	args := []string{"log", root + "/tags/" + tag, "-v", "--stop-on-copy"}
-	cmd, err := getCmd(path, args)
-	if err != nil {
-		return ret, err
-	}
-
-	out, err := cmd.CombinedOutput()
-	logger.Printf("err=%s", err)
-	logger.Printf("out=%s", string(out))
+	out, err := d.run(ctx, path, args)
 	re := regexp.MustCompile(`\s+A\s+\/tags\/[^\s]+\s+\(from \/[^:]+:([0-9]+)\)`)
 	res := re.FindStringSubmatch(string(out))
 	if len(res) > 0 {
@@ -348,6 +787,16 @@ func GetRevisionTag(path string, tag string) (string, error) {
 
 // GetFirstRevision returns the first revision of the repository.
 func GetFirstRevision(path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(context.Background(), path)
+}
+
+// GetFirstRevisionContext is like GetFirstRevision, bounded by ctx.
+func GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
+	return Driver{}.GetFirstRevisionContext(ctx, path)
+}
+
+// GetFirstRevisionContext returns the first revision of the repository.
+func (d Driver) GetFirstRevisionContext(ctx context.Context, path string) (string, error) {
 	return "1", nil
 }
 
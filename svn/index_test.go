@@ -0,0 +1,115 @@
+package svn
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mh-cbon/go-repo-utils/commit"
+)
+
+func TestParseSvnXMLLog(t *testing.T) {
+	log := `<?xml version="1.0" encoding="UTF-8"?>
+<log>
+<logentry revision="3">
+<author>vagrant</author>
+<date>2017-01-01T08:00:00.123456Z</date>
+<paths>
+<path action="D" kind="file">/trunk/a</path>
+<path action="A" copyfrom-path="/trunk/a" copyfrom-rev="2" kind="file">/trunk/b</path>
+<path action="M" kind="file">/trunk/c</path>
+</paths>
+<msg>tomate 1.0.0
+
+------------------------------------------------------------------------
+r1 | x | y</msg>
+</logentry>
+</log>`
+	commits, err := ParseSvnXMLLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(commits))
+	}
+	c := commits[0]
+	if c.Revision != "3" || c.Author != "vagrant" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	if c.Message != "tomate 1.0.0\n\n------------------------------------------------------------------------\nr1 | x | y" {
+		t.Errorf("Unexpected message %q", c.Message)
+	}
+	if reflect.DeepEqual(c.Parents, []string{"2"}) == false {
+		t.Errorf("Expected parents [2], got %v", c.Parents)
+	}
+	files := []commit.File{
+		{Action: commit.Renamed, Path: "/trunk/b", From: "/trunk/a"},
+		{Action: commit.Modified, Path: "/trunk/c"},
+	}
+	if reflect.DeepEqual(c.Files, files) == false {
+		t.Errorf("Expected files %+v, got %+v", files, c.Files)
+	}
+	if c.Date != "2017-01-01T08:00:00Z" || c.Time.IsZero() {
+		t.Errorf("Unexpected date %q", c.Date)
+	}
+}
+
+func TestBranch(t *testing.T) {
+	for url, branch := range map[string]string{
+		"^/trunk":            "trunk",
+		"^/branches/feature": "feature",
+		"^/tags/v1.0.0/sub":  "v1.0.0",
+		"^/other":            "",
+	} {
+		if got := Branch(url); got != branch {
+			t.Errorf("Branch(%q): expected %q, got %q", url, branch, got)
+		}
+	}
+}
+
+func TestParseSvnTagsLog(t *testing.T) {
+	log := `<?xml version="1.0" encoding="UTF-8"?>
+<log>
+<logentry revision="7">
+<author>john</author>
+<date>2017-01-03T08:00:00.000000Z</date>
+<paths>
+<path action="A" copyfrom-path="/trunk" copyfrom-rev="6" kind="dir">/tags/1.0.2</path>
+</paths>
+<msg>release 1.0.2</msg>
+</logentry>
+<logentry revision="5">
+<author>jane</author>
+<date>2017-01-02T08:00:00.000000Z</date>
+<paths>
+<path action="M" kind="file">/tags/1.0.0/a</path>
+</paths>
+<msg>fix the tag</msg>
+</logentry>
+<logentry revision="4">
+<author>jane</author>
+<date>2017-01-01T08:00:00.000000Z</date>
+<paths>
+<path action="A" copyfrom-path="/trunk" copyfrom-rev="3" kind="dir">/tags/1.0.0</path>
+<path action="A" kind="dir">/tags</path>
+</paths>
+<msg>tag: 1.0.0</msg>
+</logentry>
+</log>`
+	tags, err := ParseSvnTagsLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("Expected 2 tags, got %+v", tags)
+	}
+	tag := tags["1.0.0"]
+	if tag.Revision != "3" || tag.Tagger != "jane" || tag.Message != "tag: 1.0.0" || tag.Annotated == false {
+		t.Errorf("Unexpected tag %+v", tag)
+	}
+	if tag.TaggerTime.Format("2006-01-02") != "2017-01-01" {
+		t.Errorf("Unexpected tagger time %v", tag.TaggerTime)
+	}
+	if tags["1.0.2"].Revision != "6" {
+		t.Errorf("Unexpected tag %+v", tags["1.0.2"])
+	}
+}
@@ -0,0 +1,43 @@
+
+echo ""
+echo "################"
+echo "darcs"
+
+mkdir -p ~/.darcs
+echo "John Doe <john@example.com>" > ~/.darcs/author
+
+rm -fr ~/darcs
+mkdir ~/darcs
+
+# a clean repo with tags
+cd ~/darcs
+darcs init
+touch tomate-notsemvertag
+darcs add tomate-notsemvertag
+darcs record --all --skip-long-comment -m "tomate notsemvertag"
+darcs tag --skip-long-comment notsemvertag
+touch tomate-1.0.2
+darcs add tomate-1.0.2
+darcs record --all --skip-long-comment -m "tomate 1.0.2"
+darcs tag --skip-long-comment v1.0.2
+sleep 1 # need to ensure that at least one commit is not done within same second to test ordering
+touch tomate-1.0.0
+darcs add tomate-1.0.0
+darcs record --all --skip-long-comment -m "tomate 1.0.0"
+darcs tag --skip-long-comment v1.0.0
+darcs show tags
+
+# a dirty repo
+rm -fr ~/darcs_dirty
+mkdir ~/darcs_dirty
+cd ~/darcs_dirty
+darcs init
+touch mew
+darcs add mew
+
+# a repo with untracked files
+rm -fr ~/darcs_untracked
+mkdir ~/darcs_untracked
+cd ~/darcs_untracked
+darcs init
+touch mew2
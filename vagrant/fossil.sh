@@ -0,0 +1,43 @@
+
+echo ""
+echo "################"
+echo "fossil"
+
+rm -fr ~/fossil ~/fossil.fossil
+mkdir ~/fossil
+
+# a clean repo with tags
+fossil init ~/fossil.fossil
+cd ~/fossil
+fossil open ~/fossil.fossil
+touch tomate-notsemvertag
+fossil add tomate-notsemvertag
+fossil commit --no-warnings -m "tomate notsemvertag"
+fossil tag add notsemvertag current
+touch tomate-1.0.2
+fossil add tomate-1.0.2
+fossil commit --no-warnings -m "tomate 1.0.2"
+fossil tag add v1.0.2 current
+sleep 1 # need to ensure that at least one commit is not done within same second to test ordering
+touch tomate-1.0.0
+fossil add tomate-1.0.0
+fossil commit --no-warnings -m "tomate 1.0.0"
+fossil tag add v1.0.0 current
+fossil tag list
+
+# a dirty repo
+rm -fr ~/fossil_dirty ~/fossil_dirty.fossil
+mkdir ~/fossil_dirty
+fossil init ~/fossil_dirty.fossil
+cd ~/fossil_dirty
+fossil open ~/fossil_dirty.fossil
+touch mew
+fossil add mew
+
+# a repo with untracked files
+rm -fr ~/fossil_untracked ~/fossil_untracked.fossil
+mkdir ~/fossil_untracked
+fossil init ~/fossil_untracked.fossil
+cd ~/fossil_untracked
+fossil open ~/fossil_untracked.fossil
+touch mew2
@@ -28,6 +28,9 @@ git tag "v1.0.0"
 
 git tag
 
+# a sub directory to lookup the root from
+mkdir -p ~/git/sub/dir
+
 # a dirty repo
 rm -fr ~/git_dirty
 mkdir ~/git_dirty
@@ -0,0 +1,42 @@
+
+echo ""
+echo "################"
+echo "pijul"
+
+pijul identity new --no-prompt --display-name "John Doe" --email "john@example.com" || echo "has already an identity"
+
+rm -fr ~/pijul
+mkdir ~/pijul
+
+# a clean repo with tags
+cd ~/pijul
+pijul init
+touch tomate-notsemvertag
+pijul add tomate-notsemvertag
+pijul record --all -m "tomate notsemvertag"
+pijul tag create -m "notsemvertag"
+touch tomate-1.0.2
+pijul add tomate-1.0.2
+pijul record --all -m "tomate 1.0.2"
+pijul tag create -m "v1.0.2"
+sleep 1 # need to ensure that at least one commit is not done within same second to test ordering
+touch tomate-1.0.0
+pijul add tomate-1.0.0
+pijul record --all -m "tomate 1.0.0"
+pijul tag create -m "v1.0.0"
+pijul tag
+
+# a dirty repo
+rm -fr ~/pijul_dirty
+mkdir ~/pijul_dirty
+cd ~/pijul_dirty
+pijul init
+touch mew
+pijul add mew
+
+# a repo with untracked files
+rm -fr ~/pijul_untracked
+mkdir ~/pijul_untracked
+cd ~/pijul_untracked
+pijul init
+touch mew2
@@ -5,6 +5,9 @@ sh /vagrant/vagrant/bzr.sh && \
 sh /vagrant/vagrant/git.sh && \
 sh /vagrant/vagrant/hg.sh && \
 sh /vagrant/vagrant/svn.sh && \
+sh /vagrant/vagrant/fossil.sh && \
+sh /vagrant/vagrant/darcs.sh && \
+sh /vagrant/vagrant/pijul.sh && \
 echo ok"
 
 echo ""
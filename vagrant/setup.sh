@@ -1,5 +1,13 @@
 sudo apt-get install -y curl make binutils bison gcc build-essential
-sudo apt-get install -y git subversion bzr mercurial
+sudo apt-get install -y git subversion bzr mercurial fossil darcs
+
+# pijul is not packaged, it is built with cargo
+if [ ! -f ~/.cargo/bin/pijul ]; then
+  sudo apt-get install -y pkg-config libssl-dev libsodium-dev libzstd-dev libxxhash-dev
+  curl -s -S -L https://sh.rustup.rs | sh -s -- -y
+  ~/.cargo/bin/cargo install pijul --version "~1.0.0-beta"
+fi
+sudo ln -sf ~/.cargo/bin/pijul /usr/local/bin/pijul
 
 if [ ! -f go1.8.linux-amd64.tar.gz ]; then
   echo "downloading..."